@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/audit"
+	"github.com/stackgen-cli/envmerge/internal/reporter"
+)
+
+var (
+	auditFormat     string
+	auditHistory    bool
+	auditMaxCommits int
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit [path]",
+	Short: "Find secrets committed to git",
+	Long: `Check env files that contain secret-looking values and report any that
+are tracked by git or not covered by .gitignore.
+
+With --history, every commit reachable from a local branch, tag or remote
+ref is also scanned for secrets in .env* files. The .git directory is read
+directly; nothing is fetched. Each secret is reported once, at the oldest
+commit that contains it. Values are always shown redacted.
+
+Exits non-zero when anything is found.
+
+Examples:
+  envmerge audit
+  envmerge audit --history
+  envmerge audit ./myproject --history --max-commits 500 --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().StringVarP(&auditFormat, "format", "f", "text", "Output format: text, json")
+	auditCmd.Flags().BoolVar(&auditHistory, "history", false, "Also scan local git history")
+	auditCmd.Flags().IntVar(&auditMaxCommits, "max-commits", 0, "Limit the history scan to the newest N commits (0 = all)")
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	report, err := audit.Run(path, audit.Options{History: auditHistory, MaxCommits: auditMaxCommits})
+	if err != nil {
+		return fmt.Errorf("audit failed: %w", err)
+	}
+
+	switch auditFormat {
+	case "json":
+		output, err := reporter.FormatAuditJSON(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+	default:
+		fmt.Print(reporter.FormatAuditText(report))
+	}
+
+	if report.HasFindings() {
+		return fmt.Errorf("committed secrets found")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/codegen"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+var (
+	codegenLang    string
+	codegenPackage string
+	codegenOutput  string
+)
+
+var codegenCmd = &cobra.Command{
+	Use:   "codegen [path]",
+	Short: "Generate a typed config loader from the project's variables",
+	Long: `Generate code that loads and validates the project's environment, from
+every variable defined in env files and compose, plus any declared only in
+the schema. Schema types, required flags, defaults and enums carry over;
+undeclared variables become optional strings.
+
+Languages:
+  go      Config struct with env tags and a Load() function
+  ts      Env interface, zod schema and loadEnv()
+  python  pydantic-settings BaseSettings class
+
+Examples:
+  envmerge codegen --lang go --package config -o internal/config/env.go
+  envmerge codegen --lang ts -o src/env.ts
+  envmerge codegen --lang python -o app/settings.py`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCodegen,
+}
+
+func init() {
+	codegenCmd.Flags().StringVar(&codegenLang, "lang", "", "Language: "+strings.Join(codegen.Languages(), ", "))
+	codegenCmd.Flags().StringVar(&codegenPackage, "package", "config", "Package name for Go output")
+	codegenCmd.Flags().StringVarP(&codegenOutput, "output", "o", "", "Write to a file instead of stdout")
+	codegenCmd.Flags().StringVar(&schemaFile, "schema", "", "Schema file with types (default: .env.schema or .env.schema.json in path)")
+	codegenCmd.MarkFlagRequired("lang")
+}
+
+func runCodegen(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	result, err := resolver.Resolve(path)
+	if err != nil {
+		return fmt.Errorf("resolution failed: %w", err)
+	}
+	envSchema, err := loadSchema(path)
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+	schema.Annotate(envSchema, result)
+	secrets.Mark(result)
+
+	out, err := codegen.Generate(codegenLang, codegen.Fields(result, envSchema), codegen.Options{Package: codegenPackage})
+	if err != nil {
+		return err
+	}
+
+	if codegenOutput == "" {
+		fmt.Print(out)
+		return nil
+	}
+	if err := os.WriteFile(codegenOutput, []byte(out), 0644); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	fmt.Printf("✅ Written to %s\n", codegenOutput)
+	return nil
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/emit"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+var (
+	emitService   string
+	emitName      string
+	emitNamespace string
+	emitOutput    string
+	emitForce     bool
+)
+
+var emitCmd = &cobra.Command{
+	Use:   "emit",
+	Short: "Generate deployment config from the resolved environment",
+}
+
+var emitK8sCmd = &cobra.Command{
+	Use:   "k8s [path]",
+	Short: "Print a Kubernetes ConfigMap and Secret",
+	Long: `Print a ConfigMap with the plain variables and an Opaque Secret with the
+secret ones, base64 encoded. Secrets are classified the same way scan marks
+them: by schema annotations, names, known token formats and entropy.
+
+With --service, only the variables compose passes to that service are
+included. Resources are named <name>-config and <name>-secret, where name
+defaults to the service or the directory name.
+
+Examples:
+  envmerge emit k8s --service api | kubectl apply -f -
+  envmerge emit k8s --service api --namespace staging > api-env.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEmitK8s,
+}
+
+var emitComposeCmd = &cobra.Command{
+	Use:   "compose-override [path]",
+	Short: "Write a compose override pinning each service's environment",
+	Long: `Write docker-compose.override.yml with every compose service's effective
+environment set inline, so the stack runs with exactly the values envmerge
+resolved. Names declared without a value stay bare and come from the shell.
+
+The file holds resolved values, including secrets; keep it out of git.
+An existing file is only replaced with --force. Use --output - to print it.
+
+Examples:
+  envmerge emit compose-override
+  envmerge emit compose-override --output - | less`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEmitCompose,
+}
+
+func init() {
+	emitK8sCmd.Flags().StringVar(&emitService, "service", "", "Emit the environment of a specific compose service")
+	emitK8sCmd.Flags().StringVar(&emitName, "name", "", "Base name of the resources (default: service or directory name)")
+	emitK8sCmd.Flags().StringVar(&emitNamespace, "namespace", "", "Namespace of the resources")
+	emitK8sCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Let OS environment variables win over env files")
+	emitK8sCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+
+	emitComposeCmd.Flags().StringVarP(&emitOutput, "output", "o", "", "File to write, or - for stdout (default: docker-compose.override.yml in path)")
+	emitComposeCmd.Flags().BoolVar(&emitForce, "force", false, "Replace an existing override file")
+	emitComposeCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Let OS environment variables win over env files")
+	emitComposeCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+
+	emitCmd.AddCommand(emitK8sCmd)
+	emitCmd.AddCommand(emitComposeCmd)
+}
+
+func runEmitK8s(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	result, err := resolveForEmit(path)
+	if err != nil {
+		return err
+	}
+
+	entries, unset, err := serviceEnv(result, emitService)
+	if err != nil {
+		return err
+	}
+
+	name := emitName
+	if name == "" {
+		name = emitService
+	}
+	if name == "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		name = filepath.Base(abs)
+	}
+
+	out, skipped, err := emit.Kubernetes(name, emitNamespace, emitVars(result, entries, unset))
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "envmerge: skipped %s (no value or not a valid key)\n", strings.Join(skipped, ", "))
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func runEmitCompose(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	result, err := resolveForEmit(path)
+	if err != nil {
+		return err
+	}
+
+	var services []emit.Service
+	for _, svc := range result.Services() {
+		entries, unset, err := serviceEnv(result, svc)
+		if err != nil {
+			return err
+		}
+		services = append(services, emit.Service{Name: svc, Vars: emitVars(result, entries, unset)})
+	}
+	if len(services) == 0 {
+		return fmt.Errorf("no compose services in %s", path)
+	}
+
+	out, err := emit.ComposeOverride(services)
+	if err != nil {
+		return err
+	}
+
+	target := emitOutput
+	if target == "" {
+		target = filepath.Join(path, "docker-compose.override.yml")
+	}
+	if target == "-" {
+		fmt.Print(out)
+		return nil
+	}
+	if _, err := os.Stat(target); err == nil && !emitForce {
+		return fmt.Errorf("%s already exists (use --force to replace it)", target)
+	}
+	if err := os.WriteFile(target, []byte(out), 0600); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	fmt.Printf("✅ Written to %s\n", target)
+	return nil
+}
+
+// resolveForEmit resolves path and classifies secrets as scan does
+func resolveForEmit(path string) (*resolver.Resolution, error) {
+	result, err := resolver.ResolveWithOptions(path, resolver.Options{
+		IncludeOSEnv: includeOSEnv,
+		AgeKeyFile:   ageKeyFile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resolution failed: %w", err)
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "envmerge: %s\n", w)
+	}
+
+	envSchema, err := loadSchema(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+	schema.Annotate(envSchema, result)
+	secrets.Mark(result)
+	return result, nil
+}
+
+// emitVars pairs entries with their secret classification; unset names
+// have no value
+func emitVars(r *resolver.Resolution, entries []envEntry, unset []string) []emit.Var {
+	vars := make([]emit.Var, 0, len(entries)+len(unset))
+	for _, e := range entries {
+		value := e.Value
+		vars = append(vars, emit.Var{Name: e.Name, Value: &value, Secret: r.ByName[e.Name].Secret})
+	}
+	for _, name := range unset {
+		vars = append(vars, emit.Var{Name: name})
+	}
+	return vars
+}
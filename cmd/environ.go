@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// envEntry is one variable to hand to a process or write to a file
+type envEntry struct {
+	Name  string
+	Value string
+	From  resolver.Source
+}
+
+// serviceEnv collects the variables to set, sorted by name: every resolved
+// one, or only those compose passes to service. Names declared without any
+// value, such as bare compose references, are returned in unset
+func serviceEnv(r *resolver.Resolution, service string) (entries []envEntry, unset []string, err error) {
+	if err := checkService(r, service); err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(r.ByName))
+	for name := range r.ByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := r.ByName[name]
+		if service != "" && !hasServiceSource(v, service) {
+			continue
+		}
+		e, ok := lookupEnv(r, name, service)
+		if !ok {
+			if v.FinalFrom.Unresolvable {
+				fmt.Fprintf(os.Stderr, "envmerge: %s is encrypted and cannot be decrypted; not set\n", name)
+			} else {
+				unset = append(unset, name)
+			}
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, unset, nil
+}
+
+// checkService fails unless service is empty or a compose service of r
+func checkService(r *resolver.Resolution, service string) error {
+	if service != "" && !containsString(r.Services(), service) {
+		return fmt.Errorf("no compose service %q in %s", service, r.Path)
+	}
+	return nil
+}
+
+// lookupEnv returns the value a process started for service (or for the
+// project, when service is empty) would receive for name
+func lookupEnv(r *resolver.Resolution, name, service string) (envEntry, bool) {
+	src, ok := r.Lookup(name, service)
+	if !ok {
+		return envEntry{}, false
+	}
+	value := src.Value
+	if src.IsInline {
+		// compose interpolates its own file, not env_file contents
+		_, value = r.InterpolateCompose(value)
+	}
+	return envEntry{Name: name, Value: value, From: src}, true
+}
+
+// envMap turns entries into a name to value map
+func envMap(entries []envEntry) map[string]string {
+	env := make(map[string]string, len(entries))
+	for _, e := range entries {
+		env[e.Name] = e.Value
+	}
+	return env
+}
+
+func hasServiceSource(v *resolver.Variable, service string) bool {
+	for _, src := range v.Chain {
+		if src.Service == service {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
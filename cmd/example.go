@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/example"
+)
+
+var (
+	exampleCheck bool
+	examplePrune bool
+)
+
+var exampleCmd = &cobra.Command{
+	Use:   "example",
+	Short: "Manage the .env.example template",
+}
+
+var exampleSyncCmd = &cobra.Command{
+	Use:   "sync [path]",
+	Short: "Update .env.example with every key used by the project",
+	Long: `Collect every variable defined in .env* files and compose sources and
+add the missing ones to .env.example. Existing comments and ordering are
+kept; new keys are placed next to keys sharing the same prefix.
+
+Values are never copied for secret-looking variables, so the template is
+safe to commit.
+
+Use --prune to remove keys that are no longer defined anywhere else.
+Use --check to report drift without writing (exits non-zero on drift).
+
+Examples:
+  envmerge example sync
+  envmerge example sync --prune
+  envmerge example sync --check`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExampleSync,
+}
+
+func init() {
+	exampleSyncCmd.Flags().BoolVar(&exampleCheck, "check", false, "Report drift without modifying .env.example")
+	exampleSyncCmd.Flags().BoolVar(&examplePrune, "prune", false, "Remove keys not defined in any other source")
+	exampleCmd.AddCommand(exampleSyncCmd)
+}
+
+func runExampleSync(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	result, err := example.Sync(path, example.Options{
+		Check: exampleCheck,
+		Prune: examplePrune,
+	})
+	if err != nil {
+		return fmt.Errorf("example sync failed: %w", err)
+	}
+
+	drift := result.Drift
+	for _, name := range drift.Missing {
+		fmt.Printf("+ %s\n", name)
+	}
+	for _, name := range drift.Stale {
+		if examplePrune {
+			fmt.Printf("- %s\n", name)
+		} else {
+			fmt.Printf("? %s (not defined in any other source)\n", name)
+		}
+	}
+
+	switch {
+	case !drift.HasDrift():
+		fmt.Printf("✅ %s is up to date\n", result.Path)
+	case exampleCheck:
+		return fmt.Errorf("%s is out of date: %d missing, %d stale",
+			result.Path, len(drift.Missing), len(drift.Stale))
+	case result.Written:
+		fmt.Printf("\n✅ Updated %s\n", result.Path)
+	}
+
+	return nil
+}
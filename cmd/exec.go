@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+var (
+	execPath    string
+	execService string
+	execClean   bool
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] -- COMMAND [ARG...]",
+	Short: "Run a command with the resolved environment",
+	Long: `Run a command with the resolved environment layered over the current one.
+
+With --service, the command gets what compose would give that service: the
+variables from its env_file and environment entries, with ${VAR} references
+expanded. Without it, every resolved variable is set.
+
+Use --clean to start from an empty environment instead of the current one
+(the command itself is still looked up on the current PATH).
+
+Signals are forwarded to the command and its exit code is returned.
+
+Examples:
+  envmerge exec -- printenv DATABASE_URL
+  envmerge exec --service api -- go run ./cmd/api
+  envmerge exec --clean --service worker -- ./worker`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExec,
+}
+
+func init() {
+	execCmd.Flags().StringVarP(&execPath, "path", "C", ".", "Directory to resolve")
+	execCmd.Flags().StringVar(&execService, "service", "", "Use the environment of a specific compose service")
+	execCmd.Flags().BoolVar(&execClean, "clean", false, "Do not inherit the current environment")
+	execCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Let OS environment variables win over env files")
+	execCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+	// Everything after the command name belongs to the command
+	execCmd.Flags().SetInterspersed(false)
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	result, err := resolver.ResolveWithOptions(execPath, resolver.Options{
+		IncludeOSEnv: includeOSEnv,
+		AgeKeyFile:   ageKeyFile,
+	})
+	if err != nil {
+		return fmt.Errorf("resolution failed: %w", err)
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "envmerge: %s\n", w)
+	}
+
+	entries, unset, err := serviceEnv(result, execService)
+	if err != nil {
+		return err
+	}
+	env := envMap(entries)
+
+	base := os.Environ()
+	if execClean {
+		base = nil
+		// Bare compose references take their value from the shell
+		for _, name := range unset {
+			if val, ok := os.LookupEnv(name); ok && execService != "" {
+				env[name] = val
+			}
+		}
+	}
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = mergeEnv(base, env)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := child.Start(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &exitCodeError{code: exitCode(exitErr)}
+	}
+	return err
+}
+
+// mergeEnv sets env over base, keeping the result sorted for stable output
+func mergeEnv(base []string, env map[string]string) []string {
+	merged := make(map[string]string, len(base)+len(env))
+	for _, kv := range base {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			merged[name] = value
+		}
+	}
+	for name, value := range env {
+		merged[name] = value
+	}
+
+	out := make([]string, 0, len(merged))
+	for name, value := range merged {
+		out = append(out, name+"="+value)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// exitCode maps a child's exit status, using 128+N for death by signal N
+func exitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return err.ExitCode()
+}
+
+// exitCodeError makes envmerge exit with a child's status without printing
+// anything more
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/explain"
+	"github.com/stackgen-cli/envmerge/internal/reporter"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+var (
+	explainFormat  string
+	explainService string
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain VAR [path]",
+	Short: "Explain how one variable gets its value",
+	Long: `Trace a single variable through every layer: which files were checked,
+where it is defined (with the defining line), why each lower definition
+lost, how ${VAR} references in the final value expand, and which compose
+services receive which value.
+
+Use --service to see the value one compose service receives.
+
+Examples:
+  envmerge explain DATABASE_URL
+  envmerge explain DATABASE_URL --service api
+  envmerge explain DATABASE_URL ./myproject --format json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runExplain,
+}
+
+func init() {
+	explainCmd.Flags().StringVarP(&explainFormat, "format", "f", "text", "Output format: text, json")
+	explainCmd.Flags().StringVar(&explainService, "service", "", "Explain the value a specific service receives")
+	explainCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Include OS environment variables in resolution")
+	explainCmd.Flags().BoolVar(&redactValues, "redact", false, "Mask secret values in output")
+	explainCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+	explainCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show values decrypted from encrypted files")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	result, err := resolver.ResolveWithOptions(path, resolver.Options{
+		IncludeOSEnv: includeOSEnv,
+		AgeKeyFile:   ageKeyFile,
+	})
+	if err != nil {
+		return fmt.Errorf("resolution failed: %w", err)
+	}
+
+	envSchema, err := loadSchema(path)
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+	schema.Annotate(envSchema, result)
+	secrets.Mark(result)
+	result = redactResult(result, redactValues)
+
+	e, err := explain.Explain(result, args[0], explainService)
+	if err != nil {
+		return err
+	}
+
+	switch explainFormat {
+	case "json":
+		output, err := reporter.FormatExplainJSON(e)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+	default:
+		fmt.Print(reporter.FormatExplainText(e))
+	}
+	return nil
+}
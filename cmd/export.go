@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/shell"
+)
+
+var (
+	exportShell   string
+	exportService string
+	exportNoUnset bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "Print shell statements that set the resolved environment",
+	Long: `Print the resolved environment as export statements for a shell, quoted
+so that values with spaces, quotes, # or newlines survive intact.
+
+Variables the project declares without a value (such as bare compose
+references) are unset so stale values from the shell don't leak in; use
+--no-unset to leave them alone.
+
+Supported shells: ` + strings.Join(shell.Names(), ", ") + `
+
+Examples:
+  eval "$(envmerge export)"
+  envmerge export --shell fish | source
+  envmerge export --shell pwsh --service api | Invoke-Expression
+  envmerge export --shell nu | save -f env.nu`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportShell, "shell", shell.Bash, "Shell syntax: "+strings.Join(shell.Names(), ", "))
+	exportCmd.Flags().StringVar(&exportService, "service", "", "Export the environment of a specific compose service")
+	exportCmd.Flags().BoolVar(&exportNoUnset, "no-unset", false, "Do not emit unset statements")
+	exportCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Let OS environment variables win over env files")
+	exportCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if _, err := shell.Normalize(exportShell); err != nil {
+		return err
+	}
+
+	result, err := resolver.ResolveWithOptions(path, resolver.Options{
+		IncludeOSEnv: includeOSEnv,
+		AgeKeyFile:   ageKeyFile,
+	})
+	if err != nil {
+		return fmt.Errorf("resolution failed: %w", err)
+	}
+	// Output is meant for eval, so diagnostics go to stderr
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "envmerge: %s\n", w)
+	}
+
+	entries, unset, err := serviceEnv(result, exportService)
+	if err != nil {
+		return err
+	}
+	if exportNoUnset {
+		unset = nil
+	}
+
+	out, skipped, err := shell.Format(exportShell, envMap(entries), unset)
+	if err != nil {
+		return err
+	}
+	for _, name := range skipped {
+		fmt.Fprintf(os.Stderr, "envmerge: %s is not a valid shell variable name; skipped\n", name)
+	}
+	fmt.Print(out)
+	return nil
+}
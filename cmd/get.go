@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+var (
+	getService   string
+	getDefault   string
+	getWithNames bool
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get NAME [NAME...] [path]",
+	Short: "Print resolved values for scripts",
+	Long: `Print the resolved value of one or more variables, one per line, and
+nothing else. With --with-names, lines are printed as NAME=value. When more
+than one argument is given and the last one is a directory, it is the path
+to resolve.
+
+With --service, values are what exec would pass to that service, with
+${VAR} references in compose environment entries expanded.
+
+Exits non-zero if any name is undefined, unless --default is given, if the
+service does not exist, or if a value is encrypted and cannot be decrypted.
+Values are printed as resolved: secrets are not masked and values from
+encrypted files are decrypted when a key is available.
+
+Examples:
+  envmerge get DATABASE_URL
+  envmerge get PORT --service api --default 8080
+  envmerge get DB_HOST DB_PORT ./myproject --with-names`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runGet,
+}
+
+func init() {
+	getCmd.Flags().StringVar(&getService, "service", "", "Resolve as seen by a specific service")
+	getCmd.Flags().StringVar(&getDefault, "default", "", "Value to print for undefined names")
+	getCmd.Flags().BoolVar(&getWithNames, "with-names", false, "Print NAME=value lines")
+	getCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Include OS environment variables in resolution")
+	getCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 1 {
+		if info, err := os.Stat(args[len(args)-1]); err == nil && info.IsDir() {
+			path = args[len(args)-1]
+			args = args[:len(args)-1]
+		}
+	}
+
+	result, err := resolver.ResolveWithOptions(path, resolver.Options{
+		IncludeOSEnv: includeOSEnv,
+		AgeKeyFile:   ageKeyFile,
+	})
+	if err != nil {
+		return fmt.Errorf("resolution failed: %w", err)
+	}
+	if err := checkService(result, getService); err != nil {
+		return err
+	}
+
+	useDefault := cmd.Flags().Changed("default")
+	var undefined, unresolvable []string
+	for _, name := range args {
+		e, ok := getEnv(result, name)
+		value := e.Value
+		switch {
+		case ok:
+		case isUnresolvable(result, name):
+			unresolvable = append(unresolvable, name)
+			continue
+		case useDefault:
+			value = getDefault
+		default:
+			undefined = append(undefined, name)
+			continue
+		}
+
+		if getWithNames {
+			fmt.Printf("%s=%s\n", name, value)
+		} else {
+			fmt.Println(value)
+		}
+	}
+
+	if len(unresolvable) > 0 {
+		return fmt.Errorf("encrypted and cannot be decrypted: %s", strings.Join(unresolvable, ", "))
+	}
+	if len(undefined) > 0 {
+		return fmt.Errorf("undefined: %s", strings.Join(undefined, ", "))
+	}
+	return nil
+}
+
+// getEnv looks name up the way exec sets it for --service
+func getEnv(r *resolver.Resolution, name string) (envEntry, bool) {
+	if v, ok := r.ByName[name]; !ok || (getService != "" && !hasServiceSource(v, getService)) {
+		return envEntry{}, false
+	}
+	return lookupEnv(r, name, getService)
+}
+
+// isUnresolvable reports whether name has a value that could not be
+// decrypted, for --service or the project
+func isUnresolvable(r *resolver.Resolution, name string) bool {
+	v, ok := r.ByName[name]
+	if !ok {
+		return false
+	}
+	src := v.FinalFrom
+	if getService != "" {
+		src, _ = v.ForService(getService)
+	}
+	return src.Unresolvable
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/graph"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+var graphFormat string
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [path]",
+	Short: "Draw how files, layers, variables and services connect",
+	Long: `Print a graph of env files grouped by layer, the variables they define
+and the compose services that receive them, for Graphviz (dot) or Mermaid.
+
+Edges show every definition, with the winning one highlighted and the
+overridden ones dashed, env_file references from services, and ${VAR}
+references between variables. Values are never included.
+
+Examples:
+  envmerge graph | dot -Tsvg > env.svg
+  envmerge graph --format mermaid > env.mmd
+  envmerge graph ./myproject --service api`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraph,
+}
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", "dot", "Output format: dot, mermaid")
+	graphCmd.Flags().StringVar(&serviceName, "service", "", "Only show a specific service's variables")
+	graphCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Include OS environment variables in resolution")
+	graphCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	result, err := resolver.ResolveWithOptions(path, resolver.Options{
+		IncludeOSEnv: includeOSEnv,
+		ServiceName:  serviceName,
+		AgeKeyFile:   ageKeyFile,
+	})
+	if err != nil {
+		return fmt.Errorf("resolution failed: %w", err)
+	}
+
+	g := graph.Build(result)
+	switch graphFormat {
+	case "dot":
+		fmt.Print(graph.FormatDOT(g))
+	case "mermaid":
+		fmt.Print(graph.FormatMermaid(g))
+	default:
+		return fmt.Errorf("unknown format %q (want dot or mermaid)", graphFormat)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/config"
+	"github.com/stackgen-cli/envmerge/internal/lint"
+	"github.com/stackgen-cli/envmerge/internal/reporter"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+	"github.com/stackgen-cli/envmerge/internal/textdiff"
+)
+
+var (
+	lintFormat    string
+	lintConfig    string
+	lintSchema    string
+	lintListRules bool
+	lintFix       bool
+	lintDryRun    bool
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [path]",
+	Short: "Check env files for common mistakes",
+	Long: `Run lint rules over every env file and the resolved environment.
+
+Rules can be tuned in .envmerge.yml:
+
+  lint:
+    rules:
+      key-case: off
+      redundant-override: warning
+
+A single line can be excluded with a comment directly above it:
+
+  # envmerge-disable-next-line unquoted-spaces
+  GREETING=hello world
+
+Use --fix to rewrite files in place for problems that are safe to repair
+(exact duplicate keys, quoting, trailing whitespace, unsorted keys, keys
+missing from .env.example). Add --dry-run to print a unified diff instead.
+
+Exits non-zero when any error-level problem is found.
+
+Examples:
+  envmerge lint
+  envmerge lint ./myproject --format json
+  envmerge lint --fix --dry-run
+  envmerge lint --format sarif > lint.sarif
+  envmerge lint --format github
+  envmerge lint --list-rules`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLint,
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "f", "text", "Output format: text, json, sarif, junit, github")
+	lintCmd.Flags().StringVar(&lintConfig, "config", "", "Config file (default: .envmerge.yml in path)")
+	lintCmd.Flags().StringVar(&lintSchema, "schema", "", "Schema file used by schema-aware rules")
+	lintCmd.Flags().BoolVar(&lintListRules, "list-rules", false, "List available rules and exit")
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "Fix safely fixable problems in place")
+	lintCmd.Flags().BoolVar(&lintDryRun, "dry-run", false, "Print the fixes as a unified diff instead of writing (implies --fix)")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if lintListRules {
+		for _, r := range lint.Rules() {
+			fmt.Printf("%-20s %-8s %s\n", r.ID, r.Severity, r.Description)
+		}
+		return nil
+	}
+
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	ctx, diags, err := collectDiagnostics(path, lintConfig, lintSchema)
+	if err != nil {
+		return err
+	}
+
+	if lintFix || lintDryRun {
+		changes := lint.Fix(ctx, diags)
+		if lintDryRun {
+			for _, c := range changes {
+				fmt.Print(textdiff.Unified(c.Path, c.Path, c.Before, c.After))
+			}
+			return nil
+		}
+
+		if err := lint.Write(changes); err != nil {
+			return fmt.Errorf("failed to write fixes: %w", err)
+		}
+		for _, c := range changes {
+			fmt.Printf("🔧 Fixed %d problem(s) in %s\n", c.Fixed, c.Path)
+		}
+		if len(changes) > 0 {
+			fmt.Println()
+		}
+
+		// Report what is left after fixing
+		if _, diags, err = collectDiagnostics(path, lintConfig, lintSchema); err != nil {
+			return err
+		}
+	}
+
+	switch lintFormat {
+	case "sarif":
+		output, err := reporter.FormatSARIF(reporter.LintRules(), diags, version)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+	case "junit":
+		output, err := reporter.FormatJUnitByRule("envmerge lint", reporter.LintRules(), diags)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+	case "github":
+		fmt.Print(reporter.FormatGitHub(diags))
+	case "json":
+		output, err := reporter.FormatDiagnosticsJSON(diags)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+	default:
+		fmt.Print(reporter.FormatDiagnosticsText(diags))
+	}
+
+	if lint.HasErrors(diags) {
+		return fmt.Errorf("lint failed")
+	}
+	return nil
+}
+
+// collectDiagnostics resolves path and runs the configured lint rules
+func collectDiagnostics(path, configPath, schemaPath string) (*lint.Context, []lint.Diagnostic, error) {
+	cfg, err := config.Load(path, configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	result, err := resolver.Resolve(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolution failed: %w", err)
+	}
+
+	envSchema, err := schema.Discover(path, schemaPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+
+	ctx, err := lint.NewContext(result, envSchema)
+	if err != nil {
+		return nil, nil, err
+	}
+	diags, err := lint.Run(ctx, cfg.Lint)
+	return ctx, diags, err
+}
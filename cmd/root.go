@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -22,10 +23,18 @@ It traces precedence across:
   - compose inline environment blocks
 
 Use it to understand silent misconfigurations before they cause problems.`,
+	// Commands such as lint fail on findings; errors are printed by Execute
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		// exec passes its command's exit status through untouched
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -33,6 +42,17 @@ func Execute() {
 
 func init() {
 	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(explainCmd)
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(emitCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(exampleCmd)
+	rootCmd.AddCommand(codegenCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(auditCmd)
 	rootCmd.AddCommand(versionCmd)
 }
 
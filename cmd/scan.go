@@ -3,19 +3,36 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
 	"github.com/stackgen-cli/envmerge/internal/resolver"
 	"github.com/stackgen-cli/envmerge/internal/reporter"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
 )
 
 var (
 	outputFile    string
 	outputFormat  string
+	templateFile  string
+	templateText  string
 	includeOSEnv  bool
 	serviceName   string
 	strictMode    bool
 	compareWith   string
+	compareIgnore []string
+	compareFailOn string
+	schemaFile    string
+	redactValues  bool
+	ageKeyFile    string
+	showSecrets   bool
+
+	outputDir      string
+	keepEmpty      bool
+	withProvenance bool
 )
 
 var scanCmd = &cobra.Command{
@@ -28,7 +45,21 @@ environment variable showing the full precedence chain.
 Use --include-os-env to include system environment variables in resolution.
 Use --service to filter to a specific service's variables.
 Use --strict to fail if any variables are referenced but not defined.
-Use --compare to compare with another environment directory.
+Use --compare to compare with another environment directory; --ignore skips
+keys expected to differ and --fail-on sets when the comparison fails.
+Use --redact to mask secret values in the report (on by default for markdown
+and html); env files written by --output and --output-dir keep real values.
+Use --output or --output-dir to write effective env files; values are quoted
+so they parse back exactly, and --provenance notes where each came from.
+Use --template or --template-string to render the resolution through a Go
+text/template, with the helpers redact, quote, shellescape, json, toUpper
+and byService.
+Use --schema to validate values against a schema (.env.schema or JSON Schema).
+SOPS-encrypted .env.* files (dotenv, YAML or JSON) are decrypted with the age
+identity in --age-key or $SOPS_AGE_KEY_FILE; their values stay redacted in
+the report unless --show-secrets is given.
+A .env.schema or .env.schema.json file in the scanned path is used automatically,
+as are @type/@required/@secret annotations in .env.example comments.
 
 Examples:
   envmerge scan
@@ -36,18 +67,40 @@ Examples:
   envmerge scan --include-os-env
   envmerge scan --service api
   envmerge scan --strict
-  envmerge scan --compare ./staging`,
+  envmerge scan --format sarif > envmerge.sarif
+  envmerge scan --format junit > envmerge-junit.xml
+  envmerge scan --format ndjson | jq -c 'select(.overridden)'
+  envmerge scan --template tfvars.tmpl > terraform.tfvars
+  envmerge scan --template-string '{{range byService "api"}}{{.Name}}={{shellescape .FinalValue}}{{"\n"}}{{end}}'
+  envmerge scan --compare ./staging
+  envmerge scan --compare ./staging --ignore '*_URL' --fail-on any
+  envmerge scan --compare ./staging --format json
+  envmerge scan --format html --compare ./staging > report.html
+  envmerge scan --output-dir ./effective --provenance
+  envmerge scan --schema env.schema.json --strict
+  envmerge scan --age-key ~/.config/sops/age/keys.txt`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runScan,
 }
 
 func init() {
 	scanCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write resolved env to file (e.g., .env.effective)")
-	scanCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format: text, json, markdown")
+	scanCmd.Flags().StringVar(&outputDir, "output-dir", "", "Write one <service>.env per compose service into this directory")
+	scanCmd.Flags().BoolVar(&keepEmpty, "keep-empty", false, "Keep variables with empty values in written env files")
+	scanCmd.Flags().BoolVar(&withProvenance, "provenance", false, "Add a '# from file:line' comment above each written variable")
+	scanCmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format: "+strings.Join(reporter.Formats(), ", "))
+	scanCmd.Flags().StringVar(&templateFile, "template", "", "Render output with a Go text/template file instead of --format")
+	scanCmd.Flags().StringVar(&templateText, "template-string", "", "Render output with an inline Go text/template")
 	scanCmd.Flags().BoolVar(&includeOSEnv, "include-os-env", false, "Include OS environment variables in resolution")
 	scanCmd.Flags().StringVar(&serviceName, "service", "", "Filter to specific service's variables")
 	scanCmd.Flags().BoolVar(&strictMode, "strict", false, "Fail if any variables are undefined")
 	scanCmd.Flags().StringVar(&compareWith, "compare", "", "Compare with another environment directory")
+	scanCmd.Flags().StringSliceVar(&compareIgnore, "ignore", nil, "With --compare, skip variables matching these glob patterns (e.g. '*_URL')")
+	scanCmd.Flags().StringVar(&compareFailOn, "fail-on", "", "With --compare, exit non-zero on missing, different or any differences")
+	scanCmd.Flags().StringVar(&schemaFile, "schema", "", "Validate against a schema file (.env.schema format or JSON Schema)")
+	scanCmd.Flags().BoolVar(&redactValues, "redact", false, "Mask secret values in output (default true for markdown and html)")
+	scanCmd.Flags().StringVar(&ageKeyFile, "age-key", "", "age identity file for SOPS-encrypted files (default: $SOPS_AGE_KEY_FILE)")
+	scanCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show values decrypted from encrypted files in the report")
 }
 
 func runScan(cmd *cobra.Command, args []string) error {
@@ -56,11 +109,21 @@ func runScan(cmd *cobra.Command, args []string) error {
 		path = args[0]
 	}
 
+	if err := checkCompareFlags(cmd); err != nil {
+		return err
+	}
+
+	format, err := scanFormatter()
+	if err != nil {
+		return err
+	}
+
 	// Build options
 	opts := resolver.Options{
 		IncludeOSEnv: includeOSEnv,
 		ServiceName:  serviceName,
 		StrictMode:   strictMode,
+		AgeKeyFile:   ageKeyFile,
 	}
 
 	// Resolve all environment variables
@@ -69,67 +132,243 @@ func runScan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resolution failed: %w", err)
 	}
 
+	// Validate against schema if one is available
+	envSchema, err := loadSchema(path)
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w", err)
+	}
+	schema.Annotate(envSchema, result)
+	secrets.Mark(result)
+	schema.Validate(envSchema, result)
+
+	// Only the report is redacted; written env files get the real values
+	redact := redactValues
+	if !cmd.Flags().Changed("redact") {
+		redact = outputFormat == "markdown" || outputFormat == "html"
+	}
+	report := redactResult(result, redact)
+
 	// Handle compare mode
 	if compareWith != "" {
 		secondResult, err := resolver.ResolveWithOptions(compareWith, opts)
 		if err != nil {
 			return fmt.Errorf("failed to resolve comparison path: %w", err)
 		}
+		secondSchema, err := loadSchema(compareWith)
+		if err != nil {
+			return fmt.Errorf("failed to load schema for comparison path: %w", err)
+		}
+		schema.Annotate(secondSchema, secondResult)
+		secrets.Mark(secondResult)
+		secondResult = redactResult(secondResult, redact)
 
-		comparison := resolver.Compare(result, secondResult)
-		fmt.Println(resolver.FormatCompare(path, compareWith, comparison))
-		return nil
+		comparison := resolver.CompareIgnoring(report, secondResult, compareIgnore)
+		if err := format(os.Stdout, report, reporter.Options{
+			Version: version,
+			Compare: &reporter.Comparison{First: path, Second: compareWith, Result: comparison},
+		}); err != nil {
+			return err
+		}
+		return compareFailure(comparison)
 	}
 
 	// Output based on format
-	switch outputFormat {
-	case "json":
-		output, err := reporter.FormatJSON(result)
+	if err := format(os.Stdout, report, reporter.Options{Version: version}); err != nil {
+		return err
+	}
+
+	// Write effective env file if requested
+	if outputFile != "" {
+		if err := writeEffectiveEnv(effectiveEntries(result), outputFile, ""); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		fmt.Printf("\n✅ Written to %s\n", outputFile)
+	}
+	if outputDir != "" {
+		written, err := writeServiceEnvs(result, outputDir)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		for _, path := range written {
+			fmt.Printf("✅ Written to %s\n", path)
 		}
-		fmt.Println(output)
-	case "markdown":
-		output, err := reporter.FormatMarkdown(result)
+	}
+
+	if strictMode && len(result.Violations) > 0 {
+		return fmt.Errorf("strict mode: %d schema violation(s)", len(result.Violations))
+	}
+
+	return nil
+}
+
+// scanFormatter picks the formatter for --format, or for --template and
+// --template-string, which take its place
+func scanFormatter() (reporter.Formatter, error) {
+	switch {
+	case templateFile != "" && templateText != "":
+		return nil, fmt.Errorf("--template and --template-string cannot be used together")
+	case templateFile != "":
+		data, err := os.ReadFile(templateFile)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to read template: %w", err)
 		}
-		fmt.Println(output)
-	default:
-		output, err := reporter.FormatText(result)
+		tmpl, err := reporter.ParseTemplate(filepath.Base(templateFile), string(data))
 		if err != nil {
-			return err
+			return nil, err
 		}
-		fmt.Println(output)
+		return reporter.TemplateFormatter(tmpl), nil
+	case templateText != "":
+		tmpl, err := reporter.ParseTemplate("template", templateText)
+		if err != nil {
+			return nil, err
+		}
+		return reporter.TemplateFormatter(tmpl), nil
 	}
 
-	// Write effective env file if requested
-	if outputFile != "" {
-		if err := writeEffectiveEnv(result, outputFile); err != nil {
-			return fmt.Errorf("failed to write output: %w", err)
+	if compareWith != "" {
+		format, ok := reporter.LookupCompare(outputFormat)
+		if !ok {
+			return nil, fmt.Errorf("format %q does not support --compare (want one of: %s)", outputFormat, strings.Join(reporter.CompareFormats(), ", "))
 		}
-		fmt.Printf("\n✅ Written to %s\n", outputFile)
+		return format, nil
 	}
 
+	format, ok := reporter.Lookup(outputFormat)
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (want one of: %s)", outputFormat, strings.Join(reporter.Formats(), ", "))
+	}
+	return format, nil
+}
+
+// checkCompareFlags rejects --compare combinations that cannot work, and
+// compare flags given without --compare, before anything is resolved
+func checkCompareFlags(cmd *cobra.Command) error {
+	if compareWith == "" {
+		for _, name := range []string{"fail-on", "ignore"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s requires --compare", name)
+			}
+		}
+		return nil
+	}
+	if templateFile != "" || templateText != "" {
+		return fmt.Errorf("--template cannot be used with --compare")
+	}
+	switch compareFailOn {
+	case "", "missing", "different", "any":
+	default:
+		return fmt.Errorf("unknown --fail-on %q (want missing, different or any)", compareFailOn)
+	}
+	if err := resolver.CheckPatterns(compareIgnore); err != nil {
+		return fmt.Errorf("--ignore: %w", err)
+	}
 	return nil
 }
 
-func writeEffectiveEnv(result *resolver.Resolution, path string) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
+// compareFailure returns an error when --fail-on is met
+func compareFailure(c *resolver.CompareResult) error {
+	missing, different := c.Missing(), len(c.Different)
+	failed := false
+	switch compareFailOn {
+	case "missing":
+		failed = missing > 0
+	case "different":
+		failed = different > 0
+	case "any":
+		failed = missing > 0 || different > 0
+	}
+	if failed {
+		return fmt.Errorf("compare failed: %d missing, %d different", missing, different)
 	}
-	defer f.Close()
+	return nil
+}
 
-	fmt.Fprintln(f, "# Generated by envmerge - effective environment values")
-	fmt.Fprintln(f, "# This file shows the final resolved values after all overrides")
-	fmt.Fprintln(f, "")
+// redactResult masks secrets when asked to, and decrypted values unless
+// --show-secrets is given. It is for what scan prints, never for the env
+// files it writes
+func redactResult(r *resolver.Resolution, redact bool) *resolver.Resolution {
+	if redact {
+		return secrets.Redact(r)
+	}
+	if !showSecrets {
+		return secrets.RedactEncrypted(r)
+	}
+	return r
+}
 
-	for _, v := range result.Variables {
-		if v.FinalValue != "" {
-			fmt.Fprintf(f, "%s=%s\n", v.Name, v.FinalValue)
+// loadSchema reads --schema (or a default schema file) merged with any
+// annotations in .env.example
+func loadSchema(path string) (*schema.Schema, error) {
+	return schema.Discover(path, schemaFile)
+}
+
+// effectiveEntries lists the resolved variables of a scan; values that
+// could not be decrypted are left out
+func effectiveEntries(r *resolver.Resolution) []envEntry {
+	var entries []envEntry
+	for _, v := range r.Variables {
+		if v.FinalFrom.Unresolvable {
+			continue
 		}
+		entries = append(entries, envEntry{Name: v.Name, Value: v.FinalValue, From: v.FinalFrom})
 	}
+	return entries
+}
 
-	return nil
+// writeServiceEnvs writes one <service>.env per compose service into dir
+func writeServiceEnvs(r *resolver.Resolution, dir string) ([]string, error) {
+	services := r.Services()
+	if serviceName != "" {
+		services = []string{serviceName}
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no compose services found in %s", r.Path)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, svc := range services {
+		entries, _, err := serviceEnv(r, svc)
+		if err != nil {
+			return written, err
+		}
+		path := filepath.Join(dir, svc+".env")
+		if err := writeEffectiveEnv(entries, path, "service "+svc); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// writeEffectiveEnv writes entries as a dotenv file that parses back to the
+// same values, optionally noting where each one came from
+func writeEffectiveEnv(entries []envEntry, path, scope string) error {
+	doc := &dotenv.Document{}
+	header := "Generated by envmerge - effective environment values"
+	if scope != "" {
+		header += " for " + scope
+	}
+	doc.Lines = append(doc.Lines,
+		dotenv.NewComment(header),
+		dotenv.NewComment("This file shows the final resolved values after all overrides"),
+		&dotenv.Line{Kind: dotenv.LineBlank},
+	)
+
+	for _, e := range entries {
+		if e.Value == "" && !keepEmpty {
+			continue
+		}
+		if _, err := dotenv.Encode(e.Value); err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+		if withProvenance {
+			doc.Lines = append(doc.Lines, dotenv.NewComment("from "+e.From.Label()))
+		}
+		doc.Lines = append(doc.Lines, dotenv.NewEntry(e.Name, e.Value))
+	}
+
+	return doc.WriteFile(path)
 }
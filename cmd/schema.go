@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stackgen-cli/envmerge/internal/reporter"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print schemas for envmerge's machine-readable output",
+}
+
+var schemaJSONCmd = &cobra.Command{
+	Use:   "json",
+	Short: "Print the JSON Schema of scan --format json",
+	Long: `Print a JSON Schema (draft 2020-12) describing the output of
+scan --format json, for validating it or generating client types.
+
+The output carries a schema_version field. Fields may be added within a
+version; renaming or removing one, or changing its meaning, bumps it.
+
+Examples:
+  envmerge schema json > envmerge-report.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runSchemaJSON,
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaJSONCmd)
+}
+
+func runSchemaJSON(cmd *cobra.Command, args []string) error {
+	output, err := reporter.JSONSchema()
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
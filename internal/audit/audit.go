@@ -0,0 +1,289 @@
+// Package audit looks for secrets that are, or once were, committed to git
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+	"github.com/stackgen-cli/envmerge/internal/dotenvx"
+	"github.com/stackgen-cli/envmerge/internal/example"
+	"github.com/stackgen-cli/envmerge/internal/gitrepo"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+	"github.com/stackgen-cli/envmerge/internal/sops"
+)
+
+// Options controls what Run inspects
+type Options struct {
+	History    bool // Also walk every commit reachable from a ref
+	MaxCommits int  // Stop the history walk after this many commits (0 = all)
+}
+
+// Secret is a secret-looking entry in an env file
+type Secret struct {
+	Key     string
+	Line    int
+	Reason  string
+	Preview string // Redacted, see secrets.Preview
+
+	fingerprint string
+}
+
+// FileFinding is an env file holding secrets that git does not ignore
+type FileFinding struct {
+	Path    string
+	Tracked bool // In the git index
+	Ignored bool // Matched by .gitignore
+	Secrets []Secret
+}
+
+// Problem describes what is wrong with the file
+func (f *FileFinding) Problem() string {
+	if f.Tracked {
+		return "tracked by git"
+	}
+	return "not covered by .gitignore"
+}
+
+// HistoryFinding is a secret value found in a past commit. Commit is the
+// oldest commit that contains it
+type HistoryFinding struct {
+	Commit  string
+	Date    time.Time
+	Subject string
+	File    string
+	Key     string
+	Reason  string
+	Preview string
+}
+
+// Report is the outcome of an audit
+type Report struct {
+	Path           string
+	Repository     string // Work tree root
+	Files          []FileFinding
+	History        []HistoryFinding
+	HistoryScanned bool
+	CommitsScanned int
+}
+
+// HasFindings reports whether anything needs attention
+func (r *Report) HasFindings() bool {
+	return len(r.Files) > 0 || len(r.History) > 0
+}
+
+// Run audits the project at basePath. It fails if basePath is not inside
+// a git work tree
+func Run(basePath string, opts Options) (*Report, error) {
+	repo, err := gitrepo.Open(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resolver.Resolve(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Path: basePath, Repository: repo.WorkTree}
+	if report.Files, err = auditFiles(repo, envFiles(res)); err != nil {
+		return nil, err
+	}
+
+	if opts.History {
+		report.HistoryScanned = true
+		if err := auditHistory(repo, opts, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// envFiles returns every env file the resolution read, including compose
+// env_file references
+func envFiles(res *resolver.Resolution) []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, f := range res.EnvFiles {
+		add(f)
+	}
+	// Not a layer, but the most sensitive file of all
+	keys := filepath.Join(res.Path, dotenvx.KeysFile)
+	if _, err := os.Stat(keys); err == nil {
+		add(keys)
+	}
+	for _, v := range res.Variables {
+		for _, src := range v.Chain {
+			if src.Layer == resolver.LayerComposeEnvFile {
+				add(src.File)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func auditFiles(repo *gitrepo.Repo, paths []string) ([]FileFinding, error) {
+	tracked, err := repo.TrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []FileFinding
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // Already reported as a resolver warning
+		}
+		found := scanFile(path, data)
+		if len(found) == 0 {
+			continue
+		}
+
+		rel, err := repo.RelPath(path)
+		if err != nil {
+			return nil, err
+		}
+		ignored, err := repo.IsIgnored(path)
+		if err != nil {
+			return nil, err
+		}
+		f := FileFinding{Path: path, Tracked: tracked[rel], Ignored: ignored, Secrets: found}
+		if f.Tracked || !f.Ignored {
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// scanFile returns the secrets in an env file's content. SOPS files are
+// encrypted at rest and safe to commit
+func scanFile(path string, data []byte) []Secret {
+	if _, ok := sops.IsEncrypted(data); ok {
+		return nil
+	}
+	doc, err := dotenv.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return detect(doc, isTemplate(path))
+}
+
+// detect returns the secret-looking entries of doc. Templates such as
+// .env.example are expected to name secrets, so only their values count
+func detect(doc *dotenv.Document, template bool) []Secret {
+	var found []Secret
+	for _, l := range doc.Lines {
+		if l.Kind != dotenv.LineEntry || l.Value == "" || strings.HasPrefix(l.Value, "${") {
+			continue
+		}
+		// dotenvx ciphertexts and public keys are meant to be committed
+		if dotenvx.IsEncrypted(l.Value) || dotenvx.IsPublicKey(l.Key) {
+			continue
+		}
+		var finding secrets.Finding
+		var ok bool
+		if template {
+			finding, ok = secrets.ClassifyValue(l.Value)
+		} else {
+			finding, ok = secrets.Classify(l.Key, l.Value)
+		}
+		if ok {
+			found = append(found, Secret{
+				Key:     l.Key,
+				Line:    l.Number,
+				Reason:  finding.Reason,
+				Preview: secrets.Preview(l.Value),
+
+				fingerprint: secrets.Fingerprint(l.Value),
+			})
+		}
+	}
+	return found
+}
+
+func isTemplate(path string) bool {
+	name := filepath.Base(path)
+	return name == example.FileName || strings.HasSuffix(name, ".example") || strings.HasSuffix(name, ".sample")
+}
+
+func isAuditedFile(name string) bool {
+	return resolver.IsEnvFileName(name) || name == dotenvx.KeysFile
+}
+
+func auditHistory(repo *gitrepo.Repo, opts Options, report *Report) error {
+	commits, err := repo.Commits()
+	if err != nil {
+		return err
+	}
+	if opts.MaxCommits > 0 && len(commits) > opts.MaxCommits {
+		commits = commits[:opts.MaxCommits]
+	}
+
+	trees := make(map[gitrepo.Hash][]gitrepo.File)
+	blobs := make(map[gitrepo.Hash][]Secret)
+
+	// Commits are newest first, so the last write for a key is the oldest
+	// commit that contains the value
+	type key struct{ file, name, fingerprint string }
+	oldest := make(map[key]HistoryFinding)
+
+	for _, c := range commits {
+		files, err := repo.Files(c.Tree, isAuditedFile, trees)
+		if err != nil {
+			return fmt.Errorf("commit %s: %w", c.Hash.Short(), err)
+		}
+		for _, f := range files {
+			found, ok := blobs[f.Hash]
+			if !ok {
+				data, err := repo.ReadBlob(f.Hash)
+				if err != nil {
+					return fmt.Errorf("commit %s: %s: %w", c.Hash.Short(), f.Path, err)
+				}
+				found = scanFile(f.Path, data)
+				blobs[f.Hash] = found
+			}
+
+			for _, s := range found {
+				oldest[key{f.Path, s.Key, s.fingerprint}] = HistoryFinding{
+					Commit:  c.Hash.String(),
+					Date:    c.Time,
+					Subject: c.Subject,
+					File:    f.Path,
+					Key:     s.Key,
+					Reason:  s.Reason,
+					Preview: s.Preview,
+				}
+			}
+		}
+		report.CommitsScanned++
+	}
+
+	for _, h := range oldest {
+		report.History = append(report.History, h)
+	}
+	sort.Slice(report.History, func(i, j int) bool {
+		a, b := report.History[i], report.History[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Key < b.Key
+	})
+	return nil
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	return dir
+}
+
+const stripeKey = "sk_live_abcdefghijklmnop1234"
+
+func TestRun_TrackedAndUnignoredFiles(t *testing.T) {
+	dir := newRepo(t)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".env.local\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=3000\nSTRIPE_KEY="+stripeKey+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.local"), []byte("DB_PASSWORD=hunter2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.staging"), []byte("API_TOKEN=abc123\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.example"), []byte("API_TOKEN=changeme\n"), 0644)
+	git(t, dir, "add", ".gitignore", ".env", ".env.example")
+
+	report, err := Run(dir, Options{})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	got := make(map[string]FileFinding)
+	for _, f := range report.Files {
+		got[filepath.Base(f.Path)] = f
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d file findings, want 2: %+v", len(got), report.Files)
+	}
+
+	env := got[".env"]
+	if !env.Tracked || env.Problem() != "tracked by git" {
+		t.Errorf(".env: tracked = %v, problem = %q", env.Tracked, env.Problem())
+	}
+	if len(env.Secrets) != 1 || env.Secrets[0].Key != "STRIPE_KEY" || env.Secrets[0].Line != 2 {
+		t.Errorf(".env secrets = %+v, want STRIPE_KEY on line 2", env.Secrets)
+	}
+	if strings.Contains(env.Secrets[0].Preview, stripeKey) {
+		t.Errorf("preview %q leaks the value", env.Secrets[0].Preview)
+	}
+
+	if staging := got[".env.staging"]; staging.Tracked || staging.Ignored {
+		t.Errorf(".env.staging: tracked = %v, ignored = %v, want neither", staging.Tracked, staging.Ignored)
+	}
+	if _, ok := got[".env.local"]; ok {
+		t.Error(".env.local is ignored and should not be reported")
+	}
+	if _, ok := got[".env.example"]; ok {
+		t.Error(".env.example only names secrets and should not be reported")
+	}
+}
+
+func TestRun_HistoryFindsRemovedSecret(t *testing.T) {
+	dir := newRepo(t)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".env\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "deploy"), 0755)
+	os.WriteFile(filepath.Join(dir, "deploy", "prod.env"), []byte("STRIPE_KEY="+stripeKey+"\n"), 0644)
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "add prod config")
+
+	os.WriteFile(filepath.Join(dir, "deploy", "prod.env"), []byte("STRIPE_KEY=\nPORT=80\n"), 0644)
+	git(t, dir, "commit", "-q", "-am", "remove key")
+
+	report, err := Run(dir, Options{History: true})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.CommitsScanned != 2 {
+		t.Errorf("CommitsScanned = %d, want 2", report.CommitsScanned)
+	}
+	if len(report.Files) != 0 {
+		t.Errorf("current files should be clean, got %+v", report.Files)
+	}
+	if len(report.History) != 1 {
+		t.Fatalf("got %d history findings, want 1: %+v", len(report.History), report.History)
+	}
+
+	h := report.History[0]
+	if h.File != "deploy/prod.env" || h.Key != "STRIPE_KEY" || h.Subject != "add prod config" {
+		t.Errorf("history finding = %+v", h)
+	}
+	if len(h.Commit) != 40 || strings.Contains(h.Preview, stripeKey) {
+		t.Errorf("commit = %q, preview = %q", h.Commit, h.Preview)
+	}
+}
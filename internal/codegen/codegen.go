@@ -0,0 +1,170 @@
+// Package codegen generates typed config loaders from the variables of a
+// project and their schema declarations
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+)
+
+// Field is one variable of the generated config
+type Field struct {
+	Name        string // Environment variable name
+	Type        schema.Type
+	Required    bool
+	Default     *string
+	Enum        []string
+	Secret      bool
+	Description string
+}
+
+// Options tune the generated code
+type Options struct {
+	Package string // Go package name
+}
+
+// generators maps each language to its generator
+var generators = map[string]func([]Field, Options) (string, error){
+	"go":     Go,
+	"ts":     TypeScript,
+	"python": Python,
+}
+
+// Languages lists the supported languages, sorted
+func Languages() []string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate writes the config loader for lang
+func Generate(lang string, fields []Field, opts Options) (string, error) {
+	gen, ok := generators[lang]
+	if !ok {
+		return "", fmt.Errorf("unknown language %q (want one of: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	return gen(fields, opts)
+}
+
+// Fields is the union of the resolved variables and the schema's
+// declarations, sorted by name. Variables the schema doesn't declare are
+// optional strings
+func Fields(r *resolver.Resolution, s *schema.Schema) []Field {
+	seen := make(map[string]bool)
+	var fields []Field
+	add := func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+
+		f := Field{Name: name, Type: schema.TypeString}
+		if v, ok := r.ByName[name]; ok {
+			f.Secret = v.Secret
+			f.Description = v.Description
+		}
+		if sf := s.Lookup(name); sf != nil {
+			if sf.Type != "" {
+				f.Type = sf.Type
+			}
+			f.Required = sf.Required
+			f.Default = sf.Default
+			f.Enum = sf.Enum
+			f.Secret = f.Secret || sf.Secret
+			if sf.Description != "" {
+				f.Description = sf.Description
+			}
+		}
+		if f.Default != nil && !validDefault(f.Type, *f.Default) {
+			f.Default = nil
+		}
+		fields = append(fields, f)
+	}
+
+	for _, v := range r.Variables {
+		add(v.Name)
+	}
+	if s != nil {
+		for _, name := range s.Order {
+			add(name)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// validDefault reports whether a default parses as its type, so generated
+// code never embeds a literal that doesn't compile
+func validDefault(t schema.Type, value string) bool {
+	var err error
+	switch t {
+	case schema.TypeInt, schema.TypePort:
+		_, err = strconv.Atoi(value)
+	case schema.TypeNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case schema.TypeBool:
+		_, err = strconv.ParseBool(value)
+	}
+	return err == nil
+}
+
+// commonInitialisms are kept upper case in Go and TypeScript identifiers
+var commonInitialisms = map[string]bool{
+	"API": true, "CPU": true, "DB": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "JWT": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// words splits an env var name on anything that isn't a letter or digit
+func words(name string) []string {
+	return strings.FieldsFunc(name, func(c rune) bool {
+		return !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9')
+	})
+}
+
+// pascalCase turns DATABASE_URL into DatabaseURL
+func pascalCase(name string) string {
+	var sb strings.Builder
+	for _, w := range words(name) {
+		upper := strings.ToUpper(w)
+		if commonInitialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		sb.WriteString(upper[:1] + strings.ToLower(w[1:]))
+	}
+	id := sb.String()
+	if id == "" || id[0] >= '0' && id[0] <= '9' {
+		id = "Var" + id
+	}
+	return id
+}
+
+// uniqueNames maps each field to an identifier from naming, numbering
+// any that collide
+func uniqueNames(fields []Field, naming func(string) string) []string {
+	used := make(map[string]int)
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		id := naming(f.Name)
+		used[id]++
+		if n := used[id]; n > 1 {
+			id = fmt.Sprintf("%s%d", id, n)
+		}
+		names[i] = id
+	}
+	return names
+}
+
+// comment flattens a description to a single line
+func comment(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,158 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+)
+
+func setup(t *testing.T) []Field {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=3000\nDATABASE_URL=postgres://localhost/db\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.schema"), []byte(`# Port the API listens on
+PORT type=port default=8080
+LOG_LEVEL enum=debug,info default=info
+API_URL type=url required
+DEBUG type=bool default=yes
+`), 0644)
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	s, err := schema.Discover(dir, "")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	return Fields(r, s)
+}
+
+func TestFields_UnionWithSchema(t *testing.T) {
+	fields := setup(t)
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "API_URL,DATABASE_URL,DEBUG,LOG_LEVEL,PORT" {
+		t.Fatalf("fields = %s", got)
+	}
+
+	byName := make(map[string]Field)
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+	if f := byName["PORT"]; f.Type != schema.TypePort || f.Default == nil || *f.Default != "8080" || f.Description != "Port the API listens on" {
+		t.Errorf("PORT = %+v", f)
+	}
+	if f := byName["DATABASE_URL"]; f.Type != schema.TypeString || f.Required {
+		t.Errorf("undeclared DATABASE_URL = %+v, want optional string", f)
+	}
+	if f := byName["DEBUG"]; f.Default != nil {
+		t.Errorf("DEBUG keeps default %q, which is not a bool", *f.Default)
+	}
+}
+
+// typeCheck fails the test unless src is a Go file that compiles
+func typeCheck(t *testing.T, src string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "config.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated Go does not parse: %v\n%s", err, src)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("config", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated Go does not compile: %v\n%s", err, src)
+	}
+}
+
+func TestGo(t *testing.T) {
+	out, err := Generate("go", setup(t), Options{Package: "env"})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	typeCheck(t, out)
+	for _, want := range []string{
+		"package env",
+		"APIURL      string `env:\"API_URL,required\"`",
+		"Port int `env:\"PORT\" envDefault:\"8080\"`",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Go output missing %s\n%s", want, out)
+		}
+	}
+}
+
+func TestGo_PlainStringsCompile(t *testing.T) {
+	out, err := Generate("go", []Field{{Name: "FOO", Type: schema.TypeString}}, Options{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	typeCheck(t, out)
+}
+
+func TestTypeScript(t *testing.T) {
+	out, err := Generate("ts", setup(t), Options{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	for _, want := range []string{
+		"  API_URL: string;",
+		"  DATABASE_URL?: string;",
+		`  LOG_LEVEL: "debug" | "info";`,
+		"  PORT: z.coerce.number().int().min(1).max(65535),",
+		`  PORT: "8080",`,
+		"  DEBUG: z.enum([\"true\", \"false\", \"1\", \"0\"]).transform((v) => v === \"true\" || v === \"1\").optional(),",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("TypeScript output missing %s\n%s", want, out)
+		}
+	}
+}
+
+func TestPython(t *testing.T) {
+	out, err := Generate("python", setup(t), Options{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	for _, want := range []string{
+		"from typing import Literal, Optional",
+		"from pydantic import AnyUrl, Field",
+		"    API_URL: AnyUrl = Field()",
+		`    LOG_LEVEL: Literal["debug", "info"] = Field(default="info")`,
+		`    PORT: int = Field(default=8080, ge=1, le=65535, description="Port the API listens on")`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Python output missing %s\n%s", want, out)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for in, want := range map[string]string{
+		"DATABASE_URL": "DatabaseURL",
+		"api_key":      "APIKey",
+		"2FA_SECRET":   "Var2faSecret",
+	} {
+		if got := pascalCase(in); got != want {
+			t.Errorf("pascalCase(%s) = %s, want %s", in, got, want)
+		}
+	}
+	if got := pyName("class"); got != "class_" {
+		t.Errorf("pyName(class) = %s", got)
+	}
+	if _, err := Generate("cobol", nil, Options{}); err == nil {
+		t.Error("Generate should reject unknown languages")
+	}
+}
@@ -0,0 +1,143 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/schema"
+)
+
+// goTypes maps schema types to Go types and the expression parsing v
+var goTypes = map[schema.Type]struct{ Type, Parse string }{
+	schema.TypeInt:      {"int", "strconv.Atoi(v)"},
+	schema.TypePort:     {"int", "strconv.Atoi(v)"},
+	schema.TypeNumber:   {"float64", "strconv.ParseFloat(v, 64)"},
+	schema.TypeBool:     {"bool", "strconv.ParseBool(v)"},
+	schema.TypeDuration: {"time.Duration", "time.ParseDuration(v)"},
+}
+
+// Go generates a Config struct with env tags and a Load function reading it
+// from the process environment
+func Go(fields []Field, opts Options) (string, error) {
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "config"
+	}
+	names := uniqueNames(fields, pascalCase)
+
+	imports := map[string]bool{"errors": true, "os": true}
+	for _, f := range fields {
+		switch f.Type {
+		case schema.TypeInt, schema.TypePort, schema.TypeNumber, schema.TypeBool:
+			imports["strconv"] = true
+		case schema.TypeDuration:
+			imports["time"] = true
+		}
+		// Parse, enum and required errors are built with fmt.Errorf
+		if _, typed := goTypes[f.Type]; typed || len(f.Enum) > 0 || f.Required {
+			imports["fmt"] = true
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by envmerge codegen; DO NOT EDIT.\n\n")
+	sb.WriteString("package " + pkg + "\n\nimport (\n")
+	for _, imp := range []string{"errors", "fmt", "os", "strconv", "time"} {
+		if imports[imp] {
+			sb.WriteString(strconv.Quote(imp) + "\n")
+		}
+	}
+	sb.WriteString(")\n\n")
+
+	sb.WriteString("// Config holds the environment variables of the project\ntype Config struct {\n")
+	for i, f := range fields {
+		if f.Description != "" {
+			sb.WriteString("// " + comment(f.Description) + "\n")
+		}
+		tag := f.Name
+		if f.Required {
+			tag += ",required"
+		}
+		if f.Secret {
+			tag += ",secret"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s `env:%s", names[i], goType(f.Type), strconv.Quote(tag)))
+		if f.Default != nil {
+			sb.WriteString(" envDefault:" + strconv.Quote(*f.Default))
+		}
+		sb.WriteString("`\n")
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(`// Load reads Config from the process environment, applying defaults and
+// reporting every missing or malformed variable
+func Load() (*Config, error) {
+	c := &Config{}
+	var errs []error
+`)
+	for i, f := range fields {
+		writeGoLoad(&sb, f, names[i])
+	}
+	sb.WriteString("return c, errors.Join(errs...)\n}\n\n")
+
+	sb.WriteString(`// lookup returns the variable's value, or def when it is unset
+func lookup(name string, def *string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	if def != nil {
+		return *def, true
+	}
+	return "", false
+}
+
+func ptr(s string) *string { return &s }
+`)
+
+	src, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return "", fmt.Errorf("generated Go does not parse: %w", err)
+	}
+	return string(src), nil
+}
+
+func goType(t schema.Type) string {
+	if gt, ok := goTypes[t]; ok {
+		return gt.Type
+	}
+	return "string"
+}
+
+// writeGoLoad writes the statements reading one field
+func writeGoLoad(sb *strings.Builder, f Field, id string) {
+	def := "nil"
+	if f.Default != nil {
+		def = "ptr(" + strconv.Quote(*f.Default) + ")"
+	}
+	sb.WriteString(fmt.Sprintf("if v, ok := lookup(%s, %s); ok {\n", strconv.Quote(f.Name), def))
+
+	if gt, ok := goTypes[f.Type]; ok {
+		sb.WriteString(fmt.Sprintf("if x, err := %s; err != nil {\n", gt.Parse))
+		sb.WriteString(fmt.Sprintf("errs = append(errs, fmt.Errorf(\"%%s: %%w\", %s, err))\n", strconv.Quote(f.Name)))
+		sb.WriteString("} else {\n")
+		sb.WriteString(fmt.Sprintf("c.%s = x\n}\n", id))
+	} else if len(f.Enum) > 0 {
+		quoted := make([]string, len(f.Enum))
+		for i, e := range f.Enum {
+			quoted[i] = strconv.Quote(e)
+		}
+		sb.WriteString("switch v {\n")
+		sb.WriteString(fmt.Sprintf("case %s:\nc.%s = v\n", strings.Join(quoted, ", "), id))
+		sb.WriteString(fmt.Sprintf("default:\nerrs = append(errs, fmt.Errorf(\"%%s: %%q is not one of %%s\", %s, v, %s))\n}\n",
+			strconv.Quote(f.Name), strconv.Quote(strings.Join(f.Enum, ", "))))
+	} else {
+		sb.WriteString(fmt.Sprintf("c.%s = v\n", id))
+	}
+
+	if f.Required {
+		sb.WriteString(fmt.Sprintf("} else {\nerrs = append(errs, fmt.Errorf(\"%%s is required\", %s))\n", strconv.Quote(f.Name)))
+	}
+	sb.WriteString("}\n")
+}
@@ -0,0 +1,135 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/schema"
+)
+
+// pythonKeywords cannot be used as field names
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// Python generates a pydantic-settings BaseSettings class
+func Python(fields []Field, _ Options) (string, error) {
+	imports := map[string]bool{"Field": true}
+	typing := map[string]bool{}
+
+	var body strings.Builder
+	for _, f := range fields {
+		typ := pyType(f, imports, typing)
+		if !f.Required && f.Default == nil {
+			typ = "Optional[" + typ + "]"
+			typing["Optional"] = true
+		}
+
+		var args []string
+		switch {
+		case f.Default != nil:
+			args = append(args, "default="+pyLiteral(f.Type, *f.Default))
+		case !f.Required:
+			args = append(args, "default=None")
+		}
+		if name := pyName(f.Name); name != f.Name {
+			args = append(args, "alias="+strconv.Quote(f.Name))
+		}
+		if f.Type == schema.TypePort {
+			args = append(args, "ge=1", "le=65535")
+		}
+		if f.Description != "" {
+			args = append(args, "description="+strconv.Quote(comment(f.Description)))
+		}
+		body.WriteString(fmt.Sprintf("    %s: %s = Field(%s)\n", pyName(f.Name), typ, strings.Join(args, ", ")))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Code generated by envmerge codegen; DO NOT EDIT.\n\n")
+	if len(typing) > 0 {
+		sb.WriteString("from typing import " + strings.Join(sortedKeys(typing), ", ") + "\n\n")
+	}
+	sb.WriteString("from pydantic import " + strings.Join(sortedKeys(imports), ", ") + "\n")
+	sb.WriteString("from pydantic_settings import BaseSettings, SettingsConfigDict\n\n\n")
+	sb.WriteString("class Settings(BaseSettings):\n")
+	sb.WriteString("    \"\"\"Environment variables of the project\"\"\"\n\n")
+	sb.WriteString("    model_config = SettingsConfigDict(case_sensitive=True, populate_by_name=True)\n")
+	if len(fields) > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(body.String())
+	return sb.String(), nil
+}
+
+func pyType(f Field, imports, typing map[string]bool) string {
+	switch {
+	case len(f.Enum) > 0 && !isNumeric(f.Type) && f.Type != schema.TypeBool:
+		typing["Literal"] = true
+		quoted := make([]string, len(f.Enum))
+		for i, e := range f.Enum {
+			quoted[i] = strconv.Quote(e)
+		}
+		return "Literal[" + strings.Join(quoted, ", ") + "]"
+	case f.Type == schema.TypeInt || f.Type == schema.TypePort:
+		return "int"
+	case f.Type == schema.TypeNumber:
+		return "float"
+	case f.Type == schema.TypeBool:
+		return "bool"
+	case f.Type == schema.TypeURL:
+		imports["AnyUrl"] = true
+		return "AnyUrl"
+	case f.Secret:
+		imports["SecretStr"] = true
+		return "SecretStr"
+	default:
+		return "str"
+	}
+}
+
+// pyLiteral renders a default as a Python literal of the field's type
+func pyLiteral(t schema.Type, value string) string {
+	switch t {
+	case schema.TypeInt, schema.TypePort, schema.TypeNumber:
+		return value
+	case schema.TypeBool:
+		if b, _ := strconv.ParseBool(value); b {
+			return "True"
+		}
+		return "False"
+	default:
+		return strconv.Quote(value)
+	}
+}
+
+// pyName keeps valid names as they are, so they match the environment
+func pyName(name string) string {
+	var sb strings.Builder
+	for i, c := range name {
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || i > 0 && c >= '0' && c <= '9' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	id := sb.String()
+	if pythonKeywords[id] {
+		id += "_"
+	}
+	return id
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/schema"
+)
+
+// TypeScript generates an Env interface, a zod schema validating raw
+// strings into it, and a loadEnv function
+func TypeScript(fields []Field, _ Options) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("// Code generated by envmerge codegen; DO NOT EDIT.\n\n")
+	sb.WriteString("import { z } from \"zod\";\n\n")
+
+	sb.WriteString("export interface Env {\n")
+	for _, f := range fields {
+		if f.Description != "" {
+			sb.WriteString("  /** " + strings.ReplaceAll(comment(f.Description), "*/", "* /") + " */\n")
+		}
+		optional := ""
+		if !f.Required && f.Default == nil {
+			optional = "?"
+		}
+		sb.WriteString(fmt.Sprintf("  %s%s: %s;\n", tsKey(f.Name), optional, tsType(f)))
+	}
+	sb.WriteString("}\n\n")
+
+	sb.WriteString("export const envSchema = z.object({\n")
+	for _, f := range fields {
+		sb.WriteString(fmt.Sprintf("  %s: %s,\n", tsKey(f.Name), zodType(f)))
+	}
+	sb.WriteString("});\n\n")
+
+	sb.WriteString("// Defaults are applied to the raw strings before validation\n")
+	sb.WriteString("const defaults: Record<string, string> = {\n")
+	for _, f := range fields {
+		if f.Default != nil {
+			sb.WriteString(fmt.Sprintf("  %s: %s,\n", tsKey(f.Name), jsString(*f.Default)))
+		}
+	}
+	sb.WriteString("};\n\n")
+
+	sb.WriteString(`export function loadEnv(source: Record<string, string | undefined> = process.env): Env {
+  const raw: Record<string, string | undefined> = { ...defaults };
+  for (const [key, value] of Object.entries(source)) {
+    if (value !== undefined) {
+      raw[key] = value;
+    }
+  }
+  return envSchema.parse(raw);
+}
+`)
+	return sb.String(), nil
+}
+
+func tsType(f Field) string {
+	if len(f.Enum) > 0 && !isNumeric(f.Type) && f.Type != schema.TypeBool {
+		quoted := make([]string, len(f.Enum))
+		for i, e := range f.Enum {
+			quoted[i] = jsString(e)
+		}
+		return strings.Join(quoted, " | ")
+	}
+	switch {
+	case isNumeric(f.Type):
+		return "number"
+	case f.Type == schema.TypeBool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+func zodType(f Field) string {
+	var z string
+	switch f.Type {
+	case schema.TypeInt:
+		z = "z.coerce.number().int()"
+	case schema.TypePort:
+		z = "z.coerce.number().int().min(1).max(65535)"
+	case schema.TypeNumber:
+		z = "z.coerce.number()"
+	case schema.TypeBool:
+		z = `z.enum(["true", "false", "1", "0"]).transform((v) => v === "true" || v === "1")`
+	case schema.TypeURL:
+		z = "z.string().url()"
+	case schema.TypeEmail:
+		z = "z.string().email()"
+	default:
+		z = "z.string()"
+		if len(f.Enum) > 0 {
+			quoted := make([]string, len(f.Enum))
+			for i, e := range f.Enum {
+				quoted[i] = jsString(e)
+			}
+			z = "z.enum([" + strings.Join(quoted, ", ") + "])"
+		}
+	}
+	if !f.Required && f.Default == nil {
+		z += ".optional()"
+	}
+	return z
+}
+
+func isNumeric(t schema.Type) bool {
+	return t == schema.TypeInt || t == schema.TypePort || t == schema.TypeNumber
+}
+
+// jsString quotes s; JSON strings are valid JavaScript
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// tsKey leaves identifier-like keys bare and quotes the rest
+func tsKey(name string) string {
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || c == '$' || i > 0 && c >= '0' && c <= '9') {
+			return jsString(name)
+		}
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+// Package config loads the optional .envmerge.yml project configuration
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFiles are probed, in order, in the scanned directory
+var DefaultFiles = []string{".envmerge.yml", ".envmerge.yaml"}
+
+// Config is the project configuration. Every section is optional
+type Config struct {
+	Path string `yaml:"-"` // File the config was read from, empty if none
+
+	Lint LintConfig `yaml:"lint"`
+}
+
+// LintConfig tunes the lint rule set
+type LintConfig struct {
+	// Rules maps a rule ID to a severity ("error", "warning", "info")
+	// or "off" to disable it
+	Rules map[string]string `yaml:"rules"`
+}
+
+// Load reads the config at path, or the first default file under
+// basePath when path is empty. A missing default file yields an empty
+// config
+func Load(basePath, path string) (*Config, error) {
+	if path == "" {
+		for _, name := range DefaultFiles {
+			candidate := filepath.Join(basePath, name)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg.Path = path
+
+	return cfg, nil
+}
@@ -0,0 +1,378 @@
+// Package dotenv provides a line-preserving model of .env files for tools that edit them
+package dotenv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LineKind classifies a single line of a .env file
+type LineKind int
+
+const (
+	LineBlank LineKind = iota
+	LineComment
+	LineEntry
+	LineInvalid
+)
+
+// Line is one physical line of a .env file
+type Line struct {
+	Kind   LineKind
+	Number int    // 1-based line number in the source, 0 for inserted lines
+	Raw    string // Original text without the trailing newline
+	Key    string // Set for LineEntry
+	Value  string // Unquoted value, set for LineEntry
+	Export bool   // Entry used the "export " prefix
+
+	RawValue string // Value text exactly as written after '='
+	Quote    byte   // Quote character wrapping the value, 0 if unquoted
+}
+
+// Document is a parsed .env file that can be edited and written back
+// without disturbing comments or formatting of untouched lines
+type Document struct {
+	Lines []*Line
+	CRLF  bool // Source used Windows line endings; kept when writing
+}
+
+// ParseFile parses the .env file at path
+func ParseFile(path string) (*Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a .env document using the same rules as the resolver
+func Parse(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{CRLF: bytes.Contains(data, []byte("\r\n"))}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var raw []string
+	for scanner.Scan() {
+		raw = append(raw, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(raw); i++ {
+		line := parseLine(raw[i])
+		line.Number = i + 1
+		if end := multilineEnd(line, raw, i); end > i {
+			line = parseMultiline(raw[i : end+1])
+			line.Number = i + 1
+			i = end
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+
+	return doc, nil
+}
+
+// multilineEnd returns the index of the line closing a quoted value that
+// starts on raw[i] and spans several lines, or -1. A value only continues
+// when its first line holds the opening quote and nothing else closes it
+func multilineEnd(l *Line, raw []string, i int) int {
+	if l.Kind != LineEntry || l.Quote != 0 {
+		return -1
+	}
+	value := strings.TrimLeft(l.RawValue, " \t")
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return -1
+	}
+	quote := value[0]
+	if strings.IndexByte(value[1:], quote) >= 0 {
+		return -1
+	}
+	for j := i + 1; j < len(raw); j++ {
+		if strings.HasSuffix(strings.TrimRight(raw[j], " \t"), string(quote)) {
+			return j
+		}
+	}
+	return -1
+}
+
+// parseMultiline builds one entry from the physical lines of a quoted
+// multiline value; line breaks inside the quotes are kept
+func parseMultiline(lines []string) *Line {
+	first := parseLine(lines[0])
+	l := &Line{
+		Kind:   LineEntry,
+		Raw:    strings.Join(lines, "\n"),
+		Key:    first.Key,
+		Export: first.Export,
+	}
+	l.RawValue = l.Raw[strings.Index(l.Raw, "=")+1:]
+
+	value := strings.TrimSpace(l.RawValue)
+	l.Quote = value[0]
+	l.Value = value[1 : len(value)-1]
+	return l
+}
+
+func parseLine(raw string) *Line {
+	l := &Line{Raw: raw}
+	text := strings.TrimSpace(raw)
+
+	switch {
+	case text == "":
+		l.Kind = LineBlank
+		return l
+	case strings.HasPrefix(text, "#"):
+		l.Kind = LineComment
+		return l
+	}
+
+	if strings.HasPrefix(text, "export ") {
+		l.Export = true
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+	}
+
+	parts := strings.SplitN(text, "=", 2)
+	key := ""
+	if len(parts) == 2 {
+		key = strings.TrimSpace(parts[0])
+	}
+	if key == "" {
+		l.Kind = LineInvalid
+		return l
+	}
+
+	l.Kind = LineEntry
+	l.Key = key
+	l.RawValue = raw[strings.Index(raw, "=")+1:]
+	trimmed := strings.TrimSpace(parts[1])
+	l.Value = Unquote(trimmed)
+	if l.Value != trimmed {
+		l.Quote = trimmed[0]
+	}
+	return l
+}
+
+// KeyColumn returns the 1-based column where the key starts, after any
+// indentation and export prefix
+func (l *Line) KeyColumn() int {
+	rest := strings.TrimLeft(l.Raw, " \t")
+	if l.Export {
+		rest = strings.TrimLeft(strings.TrimPrefix(rest, "export"), " \t")
+	}
+	return len(l.Raw) - len(rest) + 1
+}
+
+// ValueColumn returns the 1-based column just after the '='
+func (l *Line) ValueColumn() int {
+	return strings.Index(l.Raw, "=") + 2
+}
+
+// SetValue rewrites an entry with a new value, keeping its indentation
+// and export prefix, and quoting the value if needed
+func (l *Line) SetValue(value string) {
+	indent := l.Raw[:len(l.Raw)-len(strings.TrimLeft(l.Raw, " \t"))]
+	prefix := ""
+	if l.Export {
+		prefix = "export "
+	}
+	l.Raw = indent + prefix + l.Key + "=" + Quote(value)
+	l.RawValue = Quote(value)
+	l.Value = value
+	l.Quote = 0
+	if l.RawValue != value {
+		l.Quote = l.RawValue[0]
+	}
+}
+
+// NewEntry builds an entry line for key=value, quoting the value if needed
+func NewEntry(key, value string) *Line {
+	raw := key + "=" + Quote(value)
+	if strings.Contains(raw, "\n") {
+		return parseMultiline(strings.Split(raw, "\n"))
+	}
+	return parseLine(raw)
+}
+
+// NewComment builds a comment line; the "# " prefix is added
+func NewComment(text string) *Line {
+	return &Line{Kind: LineComment, Raw: "# " + text}
+}
+
+// Keys returns entry keys in document order, without duplicates
+func (d *Document) Keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, l := range d.Lines {
+		if l.Kind == LineEntry && !seen[l.Key] {
+			seen[l.Key] = true
+			keys = append(keys, l.Key)
+		}
+	}
+	return keys
+}
+
+// Has reports whether the document defines key
+func (d *Document) Has(key string) bool {
+	return d.Index(key) >= 0
+}
+
+// Index returns the position of the last entry for key, or -1
+func (d *Document) Index(key string) int {
+	for i := len(d.Lines) - 1; i >= 0; i-- {
+		if d.Lines[i].Kind == LineEntry && d.Lines[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// LineAt returns the position of the line parsed from source line number n, or -1
+func (d *Document) LineAt(n int) int {
+	for i, l := range d.Lines {
+		if l.Number == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// Insert places lines before position i (len(d.Lines) appends)
+func (d *Document) Insert(i int, lines ...*Line) {
+	if i < 0 || i > len(d.Lines) {
+		i = len(d.Lines)
+	}
+	out := make([]*Line, 0, len(d.Lines)+len(lines))
+	out = append(out, d.Lines[:i]...)
+	out = append(out, lines...)
+	out = append(out, d.Lines[i:]...)
+	d.Lines = out
+}
+
+// Remove deletes every entry for key and returns whether anything was
+// removed. The comment block directly above an entry goes with it only
+// when nothing but that entry sits under it; a header followed by more
+// keys belongs to them and is kept
+func (d *Document) Remove(key string) bool {
+	removed := false
+	for {
+		i := d.Index(key)
+		if i < 0 {
+			return removed
+		}
+		start, end := i, i+1
+		if end == len(d.Lines) || d.Lines[end].Kind != LineEntry {
+			for start > 0 && d.Lines[start-1].Kind == LineComment {
+				start--
+			}
+			// Don't leave two blank lines where the block was
+			if end < len(d.Lines) && d.Lines[end].Kind == LineBlank &&
+				(start == 0 || d.Lines[start-1].Kind == LineBlank) {
+				end++
+			}
+		}
+		d.Lines = append(d.Lines[:start], d.Lines[end:]...)
+		removed = true
+	}
+}
+
+// String renders the document with a trailing newline
+func (d *Document) String() string {
+	newline := "\n"
+	if d.CRLF {
+		newline = "\r\n"
+	}
+	var sb strings.Builder
+	for _, l := range d.Lines {
+		sb.WriteString(l.Raw)
+		sb.WriteString(newline)
+	}
+	return sb.String()
+}
+
+// WriteFile writes the document to path
+func (d *Document) WriteFile(path string) error {
+	return os.WriteFile(path, []byte(d.String()), 0644)
+}
+
+// Unquote strips a single pair of matching surrounding quotes
+func Unquote(s string) string {
+	if len(s) >= 2 {
+		if (s[0] == '"' && s[len(s)-1] == '"') ||
+			(s[0] == '\'' && s[len(s)-1] == '\'') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+// Quote returns value in a form that parses back to the same string. Values
+// Encode rejects are quoted as well as possible
+func Quote(value string) string {
+	quoted, err := Encode(value)
+	if err != nil {
+		return `"` + value + `"`
+	}
+	return quoted
+}
+
+// Encode returns value in a form that parses back to the same string, or an
+// error if the format cannot represent it. There are no escape sequences:
+// quotes are chosen so the value never needs any, and line breaks are kept
+// inside a quoted multiline value
+func Encode(value string) (string, error) {
+	if strings.Contains(value, "\r") {
+		return "", fmt.Errorf("value contains a carriage return")
+	}
+	if !needsQuoting(value) {
+		return value, nil
+	}
+	if !strings.Contains(value, "\n") {
+		if !strings.Contains(value, "'") {
+			return "'" + value + "'", nil
+		}
+		return `"` + value + `"`, nil
+	}
+
+	for _, quote := range []string{`"`, "'"} {
+		if fitsMultiline(value, quote) {
+			return quote + value + quote, nil
+		}
+	}
+	return "", fmt.Errorf("multiline value cannot be quoted without escapes")
+}
+
+// fitsMultiline reports whether value, wrapped in quote, parses back as one
+// multiline entry: the first line may not contain the quote and no inner
+// line may end with it
+func fitsMultiline(value, quote string) bool {
+	lines := strings.Split(value, "\n")
+	if strings.Contains(lines[0], quote) {
+		return false
+	}
+	for _, l := range lines[1 : len(lines)-1] {
+		if strings.HasSuffix(strings.TrimRight(l, " \t"), quote) {
+			return false
+		}
+	}
+	return true
+}
+
+func needsQuoting(value string) bool {
+	if value == "" {
+		return false
+	}
+	if value != strings.TrimSpace(value) {
+		return true
+	}
+	return strings.ContainsAny(value, " \t#\"'\n")
+}
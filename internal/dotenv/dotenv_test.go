@@ -0,0 +1,128 @@
+package dotenv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_PreservesLines(t *testing.T) {
+	content := "# Database\nDB_HOST=localhost\n\nexport DB_PORT=\"5432\"\nnot a var\n"
+
+	doc, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if got := doc.String(); got != content {
+		t.Errorf("String() = %q, want %q", got, content)
+	}
+
+	kinds := []LineKind{LineComment, LineEntry, LineBlank, LineEntry, LineInvalid}
+	for i, want := range kinds {
+		if doc.Lines[i].Kind != want {
+			t.Errorf("line %d kind = %v, want %v", i+1, doc.Lines[i].Kind, want)
+		}
+	}
+
+	port := doc.Lines[doc.Index("DB_PORT")]
+	if port.Value != "5432" || !port.Export {
+		t.Errorf("DB_PORT = %q (export=%v), want 5432 (export=true)", port.Value, port.Export)
+	}
+}
+
+func TestDocument_InsertAndRemove(t *testing.T) {
+	doc, err := Parse(strings.NewReader("A=1\n\n# about B\nB=2\n\nC=3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.Insert(1, NewEntry("A2", "x"))
+	if !doc.Remove("B") {
+		t.Error("Remove(B) = false, want true")
+	}
+
+	want := "A=1\nA2=x\n\nC=3\n"
+	if got := doc.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestDocument_RemoveKeepsSectionHeader(t *testing.T) {
+	content := "# Database\nDB_HOST=db\nDB_PORT=5432\n\n# Deprecated\nLEGACY=1\n\nZONE=eu\n"
+	doc, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc.Remove("DB_HOST")
+	doc.Remove("LEGACY")
+
+	want := "# Database\nDB_PORT=5432\n\nZONE=eu\n"
+	if got := doc.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestQuote_RoundTrip(t *testing.T) {
+	values := []string{"plain", "", "with space", "has#hash", "it's", `say "hi"`, `both ' and "`, " padded ",
+		"line1\nline2", "-----BEGIN KEY-----\nabc\n-----END KEY-----\n", "it's\n\"quoted\"", "\nleading", "a\n  b  "}
+
+	for _, v := range values {
+		doc, err := Parse(strings.NewReader(NewEntry("K", v).Raw + "\nNEXT=1\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Lines) != 2 || doc.Lines[0].Value != v || doc.Lines[1].Key != "NEXT" {
+			t.Errorf("round trip of %q = %+v", v, doc.Lines[0])
+		}
+	}
+}
+
+func TestEncode_Unrepresentable(t *testing.T) {
+	for _, v := range []string{"a\r\nb", "x'\"\ny", "a\nb'\nc\"\nd"} {
+		if got, err := Encode(v); err == nil {
+			t.Errorf("Encode(%q) = %q, want error", v, got)
+		}
+	}
+}
+
+func TestParse_Multiline(t *testing.T) {
+	content := "A=1\nCERT=\"-----BEGIN-----\nabc\n-----END-----\"\nB=\"unterminated\nC=3\n"
+	doc, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.String(); got != content {
+		t.Errorf("String() = %q, want %q", got, content)
+	}
+
+	cert := doc.Lines[doc.Index("CERT")]
+	if cert.Value != "-----BEGIN-----\nabc\n-----END-----" || cert.Number != 2 {
+		t.Errorf("CERT = %q on line %d", cert.Value, cert.Number)
+	}
+	// An unclosed quote stays a plain single-line value
+	if b := doc.Lines[doc.Index("B")]; b.Value != `"unterminated` || b.Number != 5 {
+		t.Errorf("B = %q on line %d", b.Value, b.Number)
+	}
+	if c := doc.Lines[doc.Index("C")]; c.Number != 6 {
+		t.Errorf("C on line %d, want 6", c.Number)
+	}
+}
+
+func TestLine_KeyColumn(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int
+	}{
+		{"PORT=1", 1},
+		{"  PORT=1", 3},
+		{"export port=1", 8},
+		{"\texport   ex=1", 11},
+		{"OPORT=PORT", 1},
+	}
+	for _, tt := range tests {
+		if got := parseLine(tt.raw).KeyColumn(); got != tt.want {
+			t.Errorf("KeyColumn(%q) = %d, want %d", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package dotenvx decrypts values written by dotenvx: "encrypted:<base64>"
+// ECIES ciphertexts over secp256k1, with private keys kept in .env.keys
+package dotenvx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+)
+
+// Prefix marks an encrypted value
+const Prefix = "encrypted:"
+
+// KeysFile holds the private keys, one DOTENV_PRIVATE_KEY* per env file
+const KeysFile = ".env.keys"
+
+// Key name prefixes
+const (
+	PublicKeyName  = "DOTENV_PUBLIC_KEY"
+	PrivateKeyName = "DOTENV_PRIVATE_KEY"
+)
+
+// ErrNoKey is returned when no private key is available for a file
+var ErrNoKey = errors.New("no private key")
+
+// IsEncrypted reports whether value is a dotenvx ciphertext
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// IsPublicKey reports whether name is a DOTENV_PUBLIC_KEY header, which
+// describes the file rather than configuring the application
+func IsPublicKey(name string) bool {
+	return name == PublicKeyName || strings.HasPrefix(name, PublicKeyName+"_")
+}
+
+var nonAlnum = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// KeySuffix returns the environment part of a key name for an env file:
+// "" for .env, "_PRODUCTION" for .env.production
+func KeySuffix(envFile string) string {
+	name := filepath.Base(envFile)
+	if name == ".env" || !strings.HasPrefix(name, ".env.") {
+		return ""
+	}
+	return "_" + nonAlnum.ReplaceAllString(strings.ToUpper(strings.TrimPrefix(name, ".env.")), "_")
+}
+
+// Keyring finds private keys in the OS environment or in the .env.keys
+// file next to each env file. The environment takes precedence, as in
+// dotenvx itself
+type Keyring struct {
+	mu    sync.Mutex
+	files map[string]map[string]string // dir -> key name -> value
+}
+
+// NewKeyring returns an empty keyring; key files are read on demand
+func NewKeyring() *Keyring {
+	return &Keyring{files: make(map[string]map[string]string)}
+}
+
+// PrivateKey returns the private key for envFile
+func (k *Keyring) PrivateKey(envFile string) (string, bool) {
+	name := PrivateKeyName + KeySuffix(envFile)
+	if v := os.Getenv(name); v != "" {
+		return v, true
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	dir := filepath.Dir(envFile)
+	keys, ok := k.files[dir]
+	if !ok {
+		keys = readKeysFile(filepath.Join(dir, KeysFile))
+		k.files[dir] = keys
+	}
+	v, ok := keys[name]
+	return v, ok && v != ""
+}
+
+func readKeysFile(path string) map[string]string {
+	keys := make(map[string]string)
+	doc, err := dotenv.ParseFile(path)
+	if err != nil {
+		return keys
+	}
+	for _, l := range doc.Lines {
+		if l.Kind == dotenv.LineEntry && strings.HasPrefix(l.Key, PrivateKeyName) {
+			keys[l.Key] = l.Value
+		}
+	}
+	return keys
+}
+
+// Decrypt opens an "encrypted:" value. privateKeys may hold several
+// comma-separated hex keys; each is tried in turn
+func Decrypt(value, privateKeys string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	err = ErrNoKey
+	for _, key := range strings.Split(privateKeys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		var plain []byte
+		if plain, err = decrypt(payload, key); err == nil {
+			return string(plain), nil
+		}
+	}
+	return "", err
+}
+
+// ECIES layout (eciesjs defaults): uncompressed ephemeral public key,
+// 16-byte nonce, 16-byte tag, then the AES-256-GCM ciphertext
+const (
+	pubKeyLen = 65
+	nonceLen  = 16
+	tagLen    = 16
+)
+
+func decrypt(payload []byte, privateKeyHex string) ([]byte, error) {
+	raw, err := hex.DecodeString(privateKeyHex)
+	if err != nil || len(raw) != 32 {
+		return nil, errors.New("invalid private key")
+	}
+	if len(payload) < pubKeyLen+nonceLen+tagLen {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	ephemeral, err := secp256k1.ParsePubKey(payload[:pubKeyLen])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %w", err)
+	}
+	priv := secp256k1.PrivKeyFromBytes(raw)
+	key, err := sharedKey(payload[:pubKeyLen], sharedPoint(priv, ephemeral))
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := payload[pubKeyLen : pubKeyLen+nonceLen]
+	tag := payload[pubKeyLen+nonceLen : pubKeyLen+nonceLen+tagLen]
+	ciphertext := payload[pubKeyLen+nonceLen+tagLen:]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, nonce, append(append([]byte{}, ciphertext...), tag...), nil)
+	if err != nil {
+		return nil, errors.New("decryption failed (wrong private key?)")
+	}
+	return plain, nil
+}
+
+// sharedPoint is priv·pub, serialized uncompressed
+func sharedPoint(priv *secp256k1.PrivateKey, pub *secp256k1.PublicKey) []byte {
+	var point, result secp256k1.JacobianPoint
+	pub.AsJacobian(&point)
+	secp256k1.ScalarMultNonConst(&priv.Key, &point, &result)
+	result.ToAffine()
+	return secp256k1.NewPublicKey(&result.X, &result.Y).SerializeUncompressed()
+}
+
+// sharedKey derives the AES key with HKDF-SHA256 over sender || shared
+func sharedKey(senderPoint, shared []byte) ([]byte, error) {
+	master := append(append([]byte{}, senderPoint...), shared...)
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, master, nil, nil), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, nonceLen)
+}
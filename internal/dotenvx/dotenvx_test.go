@@ -0,0 +1,150 @@
+package dotenvx_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenvx"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	priv, pub, err := dotenvx.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	enc, err := dotenvx.Encrypt("hello world", pub)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !dotenvx.IsEncrypted(enc) || strings.Contains(enc, "hello") {
+		t.Fatalf("Encrypt = %q", enc)
+	}
+
+	got, err := dotenvx.Decrypt(enc, priv)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if got != "hello world" {
+		t.Errorf("Decrypt = %q, want hello world", got)
+	}
+
+	// Several keys may be given; the matching one is used
+	other, _, _ := dotenvx.GenerateKeyPair()
+	if got, err := dotenvx.Decrypt(enc, other+","+priv); err != nil || got != "hello world" {
+		t.Errorf("Decrypt with key list = %q, %v", got, err)
+	}
+	if _, err := dotenvx.Decrypt(enc, other); err == nil {
+		t.Error("Decrypt with the wrong key should fail")
+	}
+}
+
+// knownCiphertext is an eciesjs-format value for knownPrivateKey, built
+// outside this package: OpenSSL ECDH over secp256k1, HKDF-SHA256 over the
+// uncompressed ephemeral and shared points, AES-256-GCM with a 16-byte
+// nonce. It pins the layout and key derivation, which a round trip through
+// Encrypt cannot
+const (
+	knownPrivateKey = "6c84d37082d2d0b5fd37b610ffebf26f0561e4a6811a65457b385ed5ccdc8a7d"
+	knownCiphertext = "encrypted:BJ4E2MyLI1nTy+9P3A4UaMxwuRJJM1xHeHjblxfnMyNx5X62ZfCV2KOnfMXaGnDN/Xj6xv+kma7XDz39uEy+0N57vhg02hrCYVrEnflGpsztJrpx5CHfRiriKyc7Pd2lXZ82uzdj4zFRK1Nf6r2+uIqMm5bnVa9lbJ0dBykXxHSpeg=="
+)
+
+func TestDecrypt_KnownAnswer(t *testing.T) {
+	got, err := dotenvx.Decrypt(knownCiphertext, knownPrivateKey)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if want := "postgres://app:s3cret@db:5432/app"; got != want {
+		t.Errorf("Decrypt = %q, want %q", got, want)
+	}
+}
+
+func TestKeySuffix(t *testing.T) {
+	tests := map[string]string{
+		".env":                 "",
+		".env.production":      "_PRODUCTION",
+		"app/.env.ci-staging":  "_CI_STAGING",
+		"deploy/secrets.env":   "",
+		"/abs/path/.env.local": "_LOCAL",
+	}
+	for file, want := range tests {
+		if got := dotenvx.KeySuffix(file); got != want {
+			t.Errorf("KeySuffix(%s) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestResolve_EncryptedValues(t *testing.T) {
+	devPriv, devPub, _ := dotenvx.GenerateKeyPair()
+	prodPriv, prodPub, _ := dotenvx.GenerateKeyPair()
+
+	devSecret, _ := dotenvx.Encrypt("dev-secret", devPub)
+	prodSecret, _ := dotenvx.Encrypt("prod-secret", prodPub)
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte(`#/---[DOTENV_PUBLIC_KEY]---/
+DOTENV_PUBLIC_KEY="`+devPub+`"
+API_SECRET="`+devSecret+`"
+PORT=3000
+`), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.production"), []byte(`DOTENV_PUBLIC_KEY_PRODUCTION="`+prodPub+`"
+API_SECRET="`+prodSecret+`"
+`), 0644)
+	// Only the development key is available locally
+	os.WriteFile(filepath.Join(dir, dotenvx.KeysFile), []byte(`DOTENV_PRIVATE_KEY="`+devPriv+`"
+`), 0600)
+
+	t.Setenv("DOTENV_PRIVATE_KEY", "")
+	t.Setenv("DOTENV_PRIVATE_KEY_PRODUCTION", "")
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	for _, f := range r.EnvFiles {
+		if filepath.Base(f) == dotenvx.KeysFile {
+			t.Errorf("%s must not be read as a layer", dotenvx.KeysFile)
+		}
+	}
+	if _, ok := r.ByName["DOTENV_PUBLIC_KEY"]; ok {
+		t.Error("DOTENV_PUBLIC_KEY should not be a variable")
+	}
+
+	v := r.ByName["API_SECRET"]
+	if len(v.Chain) != 2 {
+		t.Fatalf("API_SECRET chain has %d sources, want 2", len(v.Chain))
+	}
+	dev, prod := v.Chain[0], v.Chain[1]
+	if dev.Value != "dev-secret" || !dev.Encrypted || dev.Unresolvable {
+		t.Errorf(".env source = %+v, want decrypted dev-secret", dev)
+	}
+	if prod.Value != "" || !prod.Unresolvable {
+		t.Errorf(".env.production source = %+v, want unresolvable", prod)
+	}
+	if v.FinalValue != "" || !v.FinalFrom.Unresolvable || !v.Secret {
+		t.Errorf("API_SECRET final = %q (unresolvable %v, secret %v)", v.FinalValue, v.FinalFrom.Unresolvable, v.Secret)
+	}
+
+	if len(r.EncryptedFiles) != 2 {
+		t.Fatalf("EncryptedFiles = %+v, want 2 files", r.EncryptedFiles)
+	}
+	if f := r.EncryptedFiles[0]; !f.Decrypted || strings.Join(f.Plaintext, ",") != "PORT" {
+		t.Errorf(".env summary = %+v", f)
+	}
+	if f := r.EncryptedFiles[1]; f.Decrypted {
+		t.Errorf(".env.production summary = %+v, want not decrypted", f)
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "DOTENV_PRIVATE_KEY_PRODUCTION") {
+		t.Errorf("Warnings = %v", r.Warnings)
+	}
+
+	// A key in the environment takes effect without .env.keys
+	t.Setenv("DOTENV_PRIVATE_KEY_PRODUCTION", prodPriv)
+	r, _ = resolver.Resolve(dir)
+	if got := r.ByName["API_SECRET"].FinalValue; got != "prod-secret" {
+		t.Errorf("API_SECRET with production key = %q, want prod-secret", got)
+	}
+}
@@ -0,0 +1,60 @@
+package dotenvx
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Encrypt seals value for the hex-encoded (compressed or uncompressed)
+// public key, producing what dotenvx would write. Only tests encrypt;
+// envmerge itself never does
+func Encrypt(value, publicKeyHex string) (string, error) {
+	raw, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return "", errors.New("invalid public key")
+	}
+	recipient, err := secp256k1.ParsePubKey(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+
+	ephemeral, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return "", err
+	}
+	ephemeralPub := ephemeral.PubKey().SerializeUncompressed()
+	key, err := sharedKey(ephemeralPub, sharedPoint(ephemeral, recipient))
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, nonceLen)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, nonce, []byte(value), nil)
+	ciphertext, tag := sealed[:len(sealed)-tagLen], sealed[len(sealed)-tagLen:]
+
+	payload := append(append(append(ephemeralPub, nonce...), tag...), ciphertext...)
+	return Prefix + base64.StdEncoding.EncodeToString(payload), nil
+}
+
+// GenerateKeyPair returns a new hex-encoded private key and its compressed
+// public key, in the format dotenvx stores them
+func GenerateKeyPair() (privateKey, publicKey string, err error) {
+	priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(priv.Serialize()), hex.EncodeToString(priv.PubKey().SerializeCompressed()), nil
+}
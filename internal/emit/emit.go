@@ -0,0 +1,152 @@
+// Package emit generates deployment manifests from resolved variables
+package emit
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Var is one variable to emit. A nil Value is a name declared without a
+// value, which compose takes from the shell
+type Var struct {
+	Name   string
+	Value  *string
+	Secret bool
+}
+
+// Service is a compose service and its effective environment
+type Service struct {
+	Name string
+	Vars []Var
+}
+
+// configKeyRe matches keys allowed in a ConfigMap or Secret
+var configKeyRe = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+type k8sMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type k8sObject struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// Kubernetes renders a ConfigMap named <name>-config for plain variables
+// and an Opaque Secret named <name>-secret with the secret ones base64
+// encoded. Either is left out when it would be empty. Variables without a
+// value or with a name Kubernetes rejects are returned in skipped
+func Kubernetes(name, namespace string, vars []Var) (out string, skipped []string, err error) {
+	name = ResourceName(name)
+	config := k8sObject{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sMetadata{Name: name + "-config", Namespace: namespace},
+		Data:       map[string]string{},
+	}
+	secret := k8sObject{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   k8sMetadata{Name: name + "-secret", Namespace: namespace},
+		Type:       "Opaque",
+		Data:       map[string]string{},
+	}
+
+	for _, v := range vars {
+		if v.Value == nil || !configKeyRe.MatchString(v.Name) {
+			skipped = append(skipped, v.Name)
+			continue
+		}
+		if v.Secret {
+			secret.Data[v.Name] = base64.StdEncoding.EncodeToString([]byte(*v.Value))
+		} else {
+			config.Data[v.Name] = *v.Value
+		}
+	}
+
+	var docs []string
+	for _, obj := range []k8sObject{config, secret} {
+		if len(obj.Data) == 0 {
+			continue
+		}
+		data, err := marshal(obj)
+		if err != nil {
+			return "", nil, err
+		}
+		docs = append(docs, data)
+	}
+	return strings.Join(docs, "---\n"), skipped, nil
+}
+
+// ComposeOverride renders a compose override file that sets every
+// service's environment inline. $ is doubled so compose does not
+// interpolate values a second time
+func ComposeOverride(services []Service) (string, error) {
+	type service struct {
+		Environment map[string]*string `yaml:"environment"`
+	}
+	override := struct {
+		Services map[string]service `yaml:"services"`
+	}{Services: map[string]service{}}
+
+	for _, s := range services {
+		env := make(map[string]*string, len(s.Vars))
+		for _, v := range s.Vars {
+			if v.Value == nil {
+				env[v.Name] = nil
+				continue
+			}
+			escaped := strings.ReplaceAll(*v.Value, "$", "$$")
+			env[v.Name] = &escaped
+		}
+		override.Services[s.Name] = service{Environment: env}
+	}
+
+	data, err := marshal(override)
+	if err != nil {
+		return "", err
+	}
+	return "# Generated by envmerge emit compose-override from the resolved environment\n" + data, nil
+}
+
+// marshal writes v as YAML with the two-space indent kubectl and compose use
+func marshal(v interface{}) (string, error) {
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// ResourceName turns s into a valid Kubernetes resource name: lower case
+// alphanumerics and dashes, at most 63 characters
+func ResourceName(s string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToLower(s) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte('-')
+		}
+	}
+	name := strings.Trim(sb.String(), "-")
+	if len(name) > 56 { // Leaves room for the -config and -secret suffixes
+		name = strings.TrimRight(name[:56], "-")
+	}
+	if name == "" {
+		return "env"
+	}
+	return name
+}
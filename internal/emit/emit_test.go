@@ -0,0 +1,98 @@
+package emit
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func str(s string) *string { return &s }
+
+func TestKubernetes_SplitsSecrets(t *testing.T) {
+	out, skipped, err := Kubernetes("My API", "staging", []Var{
+		{Name: "PORT", Value: str("3000")},
+		{Name: "API_TOKEN", Value: str("s3cr3t"), Secret: true},
+		{Name: "BARE"},
+		{Name: "BAD NAME", Value: str("x")},
+	})
+	if err != nil {
+		t.Fatalf("Kubernetes failed: %v", err)
+	}
+	if strings.Join(skipped, ",") != "BARE,BAD NAME" {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	docs := strings.Split(out, "---\n")
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2:\n%s", len(docs), out)
+	}
+	var config, secret k8sObject
+	if err := yaml.Unmarshal([]byte(docs[0]), &config); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte(docs[1]), &secret); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Kind != "ConfigMap" || config.Metadata.Name != "my-api-config" || config.Metadata.Namespace != "staging" {
+		t.Errorf("config = %+v", config)
+	}
+	if config.Data["PORT"] != "3000" || len(config.Data) != 1 {
+		t.Errorf("config data = %v", config.Data)
+	}
+	if !strings.Contains(docs[0], `PORT: "3000"`) {
+		t.Errorf("numeric values should stay strings:\n%s", docs[0])
+	}
+	if secret.Kind != "Secret" || secret.Type != "Opaque" || secret.Data["API_TOKEN"] != base64.StdEncoding.EncodeToString([]byte("s3cr3t")) {
+		t.Errorf("secret = %+v", secret)
+	}
+}
+
+func TestKubernetes_OmitsEmptySecret(t *testing.T) {
+	out, _, err := Kubernetes("api", "", []Var{{Name: "PORT", Value: str("3000")}})
+	if err != nil {
+		t.Fatalf("Kubernetes failed: %v", err)
+	}
+	if strings.Contains(out, "Secret") || strings.Contains(out, "namespace") {
+		t.Errorf("output should hold only a ConfigMap without namespace:\n%s", out)
+	}
+}
+
+func TestComposeOverride_EscapesDollar(t *testing.T) {
+	out, err := ComposeOverride([]Service{
+		{Name: "api", Vars: []Var{{Name: "PASSWORD", Value: str("pa$word")}, {Name: "FROM_SHELL"}}},
+	})
+	if err != nil {
+		t.Fatalf("ComposeOverride failed: %v", err)
+	}
+
+	var parsed struct {
+		Services map[string]struct {
+			Environment map[string]*string `yaml:"environment"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(out), &parsed); err != nil {
+		t.Fatalf("invalid YAML: %v", err)
+	}
+	env := parsed.Services["api"].Environment
+	if env["PASSWORD"] == nil || *env["PASSWORD"] != "pa$$word" {
+		t.Errorf("PASSWORD = %v, want pa$$word", env["PASSWORD"])
+	}
+	if v, ok := env["FROM_SHELL"]; !ok || v != nil {
+		t.Errorf("FROM_SHELL should be present without a value")
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	for in, want := range map[string]string{
+		"api":           "api",
+		"My_Service.v2": "my-service-v2",
+		"--":            "env",
+	} {
+		if got := ResourceName(in); got != want {
+			t.Errorf("ResourceName(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
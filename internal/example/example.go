@@ -0,0 +1,181 @@
+// Package example keeps .env.example in sync with the variables a project actually uses
+package example
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+// FileName is the template file managed by this package
+const FileName = ".env.example"
+
+// Options controls how Sync rewrites the template
+type Options struct {
+	Prune bool // Remove keys that are no longer defined anywhere else
+	Check bool // Only report drift, never write
+}
+
+// Drift describes how .env.example differs from the real sources
+type Drift struct {
+	Missing []string // Defined elsewhere but absent from .env.example
+	Stale   []string // Present in .env.example but defined nowhere else
+}
+
+// HasDrift reports whether the template is out of date
+func (d *Drift) HasDrift() bool {
+	return len(d.Missing) > 0 || len(d.Stale) > 0
+}
+
+// Result is the outcome of a Sync run
+type Result struct {
+	Path    string
+	Drift   *Drift
+	Written bool
+}
+
+// Sync compares .env.example under basePath with every other source and,
+// unless opts.Check is set, updates it in place
+func Sync(basePath string, opts Options) (*Result, error) {
+	res, err := resolver.Resolve(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(basePath, FileName)
+	doc := &dotenv.Document{}
+	if _, err := os.Stat(path); err == nil {
+		doc, err = dotenv.ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	drift := Diff(res, doc)
+	result := &Result{Path: path, Drift: drift}
+
+	if opts.Check || !drift.HasDrift() {
+		return result, nil
+	}
+	if len(drift.Missing) == 0 && !opts.Prune {
+		return result, nil
+	}
+
+	Apply(doc, res, drift, opts)
+	if err := doc.WriteFile(path); err != nil {
+		return nil, err
+	}
+	result.Written = true
+
+	return result, nil
+}
+
+// Diff computes the drift between a resolution and a parsed template
+func Diff(res *resolver.Resolution, doc *dotenv.Document) *Drift {
+	drift := &Drift{}
+	defined := definedKeys(res)
+
+	for _, name := range sortedKeys(defined) {
+		if !doc.Has(name) {
+			drift.Missing = append(drift.Missing, name)
+		}
+	}
+	for _, name := range doc.Keys() {
+		if !defined[name] {
+			drift.Stale = append(drift.Stale, name)
+		}
+	}
+
+	return drift
+}
+
+// Apply edits doc to resolve drift: missing keys are inserted next to
+// related keys with placeholder values, stale keys are removed if pruning
+func Apply(doc *dotenv.Document, res *resolver.Resolution, drift *Drift, opts Options) {
+	if opts.Prune {
+		for _, name := range drift.Stale {
+			doc.Remove(name)
+		}
+	}
+
+	for _, name := range drift.Missing {
+		line := dotenv.NewEntry(name, placeholder(res.ByName[name]))
+		doc.Insert(insertionPoint(doc, name), line)
+	}
+}
+
+// definedKeys returns names that have at least one source outside .env.example
+func definedKeys(res *resolver.Resolution) map[string]bool {
+	keys := make(map[string]bool)
+	for name, v := range res.ByName {
+		for _, src := range v.Chain {
+			if src.Layer != resolver.LayerEnvExample {
+				keys[name] = true
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// placeholder picks the value written for a new key. Secret-looking and
+// decrypted variables are always left blank; others reuse their lowest-precedence
+// value, which is the closest thing to a project default
+func placeholder(v *resolver.Variable) string {
+	if v == nil {
+		return ""
+	}
+	for _, src := range v.Chain {
+		if src.Layer == resolver.LayerEnvExample {
+			continue
+		}
+		if src.Encrypted {
+			return ""
+		}
+		if _, secret := secrets.Classify(v.Name, src.Value); secret {
+			return ""
+		}
+		return src.Value
+	}
+	return ""
+}
+
+// insertionPoint finds where a new key belongs: after the last key that
+// shares its prefix (DB_HOST goes next to DB_PORT), otherwise at the end
+func insertionPoint(doc *dotenv.Document, name string) int {
+	prefix := keyPrefix(name)
+	at := -1
+	for i, l := range doc.Lines {
+		if l.Kind != dotenv.LineEntry {
+			continue
+		}
+		if prefix != "" && keyPrefix(l.Key) == prefix {
+			at = i + 1
+		}
+	}
+	if at >= 0 {
+		return at
+	}
+	return len(doc.Lines)
+}
+
+func keyPrefix(name string) string {
+	if i := strings.Index(name, "_"); i > 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
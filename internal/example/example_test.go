@@ -0,0 +1,101 @@
+package example
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSync_AddsMissingKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	exampleContent := `# Database settings
+DB_HOST=localhost
+
+# Feature flags
+FEATURE_X=false
+`
+	envContent := `DB_HOST=db
+DB_PORT=5432
+FEATURE_X=true
+STRIPE_SECRET_KEY=sk_live_abcdefghijklmnop
+`
+	if err := os.WriteFile(filepath.Join(dir, ".env.example"), []byte(exampleContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Sync(dir, Options{})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !result.Written {
+		t.Fatal("expected .env.example to be written")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ".env.example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Database settings
+DB_HOST=localhost
+DB_PORT=5432
+
+# Feature flags
+FEATURE_X=false
+STRIPE_SECRET_KEY=
+`
+	if string(data) != want {
+		t.Errorf(".env.example =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestSync_CheckDoesNotWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, ".env.example"), []byte("OLD_KEY=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("NEW_KEY=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Sync(dir, Options{Check: true})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if result.Written {
+		t.Error("check mode should not write")
+	}
+	if strings.Join(result.Drift.Missing, ",") != "NEW_KEY" {
+		t.Errorf("Missing = %v, want [NEW_KEY]", result.Drift.Missing)
+	}
+	if strings.Join(result.Drift.Stale, ",") != "OLD_KEY" {
+		t.Errorf("Stale = %v, want [OLD_KEY]", result.Drift.Stale)
+	}
+}
+
+func TestSync_Prune(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, ".env.example"), []byte("KEEP=\n# legacy\nOLD_KEY=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("KEEP=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sync(dir, Options{Prune: true}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, ".env.example"))
+	if string(data) != "KEEP=\n" {
+		t.Errorf(".env.example = %q, want %q", data, "KEEP=\n")
+	}
+}
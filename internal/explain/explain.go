@@ -0,0 +1,281 @@
+// Package explain traces how a single variable reached its final value
+package explain
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// Candidate is a file that was checked for the variable
+type Candidate struct {
+	File    string
+	Layer   resolver.Layer
+	Defined bool
+	Lines   []int // Lines defining the variable, when Defined
+}
+
+// Definition is one place the variable is set
+type Definition struct {
+	Source  resolver.Source
+	Snippet string // The defining line, or KEY=value when the value is masked or decrypted
+	Wins    bool
+	Reason  string // Why it wins or loses
+}
+
+// ServiceValue is the value a compose service receives
+type ServiceValue struct {
+	Service string
+	Value   string
+	From    resolver.Source
+}
+
+// Explanation is the full story for one variable
+type Explanation struct {
+	Variable    string
+	Service     string // Set when explaining for one compose service
+	Description string
+	Secret      bool
+
+	FinalValue string
+	FinalFrom  resolver.Source
+
+	Candidates  []Candidate
+	Definitions []Definition         // Lowest precedence first
+	Steps       []resolver.Expansion // ${VAR} references in the final value
+	Expanded    string               // FinalValue with references substituted
+	Services    []ServiceValue
+}
+
+// Explain builds the explanation for name from a resolution. With service
+// set, only project-wide sources and that service's sources can win
+func Explain(r *resolver.Resolution, name, service string) (*Explanation, error) {
+	v, ok := r.ByName[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not defined or referenced in %s", name, r.Path)
+	}
+
+	e := &Explanation{
+		Variable:    name,
+		Service:     service,
+		Description: v.Description,
+		Secret:      v.Secret,
+		FinalValue:  v.FinalValue,
+		FinalFrom:   v.FinalFrom,
+	}
+	winner := len(v.Chain) - 1
+	if service != "" {
+		winner = -1
+		for i := len(v.Chain) - 1; i >= 0; i-- {
+			if applies(v.Chain[i], service) {
+				winner = i
+				break
+			}
+		}
+		if winner < 0 {
+			return nil, fmt.Errorf("%s is not set for service %s", name, service)
+		}
+		e.FinalFrom = v.Chain[winner]
+		e.FinalValue = e.FinalFrom.Value
+	}
+
+	e.Candidates = candidates(r, v)
+	lines := make(map[string][]string)
+	for i, src := range v.Chain {
+		d := Definition{Source: src, Snippet: snippet(src, name, lines), Wins: i == winner}
+		d.Reason = reason(v.Chain, i, winner, service)
+		e.Definitions = append(e.Definitions, d)
+	}
+
+	if e.FinalFrom.IsInline {
+		e.Steps, e.Expanded = r.InterpolateCompose(e.FinalValue)
+	} else {
+		e.Steps, e.Expanded = r.Interpolate(e.FinalValue, service)
+	}
+
+	if service != "" {
+		e.Services = []ServiceValue{{Service: service, Value: e.FinalValue, From: e.FinalFrom}}
+		return e, nil
+	}
+	for _, svc := range r.Services() {
+		received := false
+		for _, src := range v.Chain {
+			if src.Service == svc {
+				received = true
+				break
+			}
+		}
+		if !received {
+			continue
+		}
+		src, _ := v.ForService(svc)
+		e.Services = append(e.Services, ServiceValue{Service: svc, Value: src.Value, From: src})
+	}
+	return e, nil
+}
+
+// applies reports whether src can reach service
+func applies(src resolver.Source, service string) bool {
+	return src.Service == "" || src.Service == service
+}
+
+// reason explains the outcome of chain[i] against the winning definition
+func reason(chain []resolver.Source, i, winner int, service string) string {
+	src := chain[i]
+	if i == winner {
+		if winner == 0 {
+			return "only definition"
+		}
+		return fmt.Sprintf("highest precedence (%s)", src.Layer)
+	}
+	if service != "" && !applies(src, service) {
+		return fmt.Sprintf("applies to service %s only", src.Service)
+	}
+	w := chain[winner]
+	switch {
+	case w.File == src.File && w.Service == src.Service && w.Line > 0:
+		return fmt.Sprintf("redefined later in the same file (line %d)", w.Line)
+	case w.Layer == src.Layer:
+		return fmt.Sprintf("%s is loaded after it in the same layer", w.Location())
+	default:
+		return fmt.Sprintf("overridden by %s (%s)", w.Location(), w.Layer)
+	}
+}
+
+// candidates lists every file the resolver read, in precedence order, and
+// whether each defines v
+func candidates(r *resolver.Resolution, v *resolver.Variable) []Candidate {
+	var out []Candidate
+	index := make(map[string]int)
+	add := func(file string, layer resolver.Layer) {
+		if _, ok := index[file]; ok {
+			return
+		}
+		index[file] = len(out)
+		out = append(out, Candidate{File: file, Layer: layer})
+	}
+
+	// Layers are known from the sources; files defining nothing fall back
+	// to their name
+	layers := make(map[string]resolver.Layer)
+	for _, src := range allSources(r) {
+		if _, ok := layers[src.File]; !ok && src.File != "" {
+			layers[src.File] = src.Layer
+		}
+	}
+	for _, f := range r.EnvFiles {
+		layer, ok := layers[f]
+		if !ok {
+			layer = layerFor(filepath.Base(f))
+		}
+		add(f, layer)
+	}
+	for _, src := range allSources(r) {
+		if src.Layer == resolver.LayerComposeEnvFile {
+			add(src.File, src.Layer)
+		}
+	}
+	for _, f := range r.ComposeFiles {
+		add(f, resolver.LayerComposeInline)
+	}
+	for _, src := range v.Chain {
+		if src.Layer == resolver.LayerOSEnv {
+			add(src.File, src.Layer)
+		}
+	}
+
+	for _, src := range v.Chain {
+		c := &out[index[src.File]]
+		c.Defined = true
+		if src.Line > 0 && !containsInt(c.Lines, src.Line) {
+			c.Lines = append(c.Lines, src.Line)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Layer.Precedence() < out[j].Layer.Precedence()
+	})
+	return out
+}
+
+// allSources returns every source of every variable in name order, so
+// files are discovered deterministically
+func allSources(r *resolver.Resolution) []resolver.Source {
+	names := make([]string, 0, len(r.ByName))
+	for name := range r.ByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []resolver.Source
+	for _, name := range names {
+		out = append(out, r.ByName[name].Chain...)
+	}
+	return out
+}
+
+func layerFor(name string) resolver.Layer {
+	switch name {
+	case ".env.example":
+		return resolver.LayerEnvExample
+	case ".env":
+		return resolver.LayerEnv
+	case ".env.local":
+		return resolver.LayerEnvLocal
+	default:
+		return resolver.LayerEnvOther
+	}
+}
+
+func containsInt(list []int, n int) bool {
+	for _, item := range list {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet returns the defining line as written. When the source value does
+// not appear in it (redacted, decrypted or unresolvable) it falls back to
+// KEY=value so nothing hidden is printed from the raw file
+func snippet(src resolver.Source, name string, cache map[string][]string) string {
+	fallback := name + "=" + src.Value
+	if src.IsInline || src.Line == 0 || src.Encrypted {
+		return fallback
+	}
+
+	lines, ok := cache[src.File]
+	if !ok {
+		lines = readLines(src.File)
+		cache[src.File] = lines
+	}
+	if src.Line > len(lines) {
+		return fallback
+	}
+	line := strings.TrimSpace(lines[src.Line-1])
+	if !strings.Contains(line, src.Value) {
+		return fallback
+	}
+	return line
+}
+
+func readLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
@@ -0,0 +1,105 @@
+package explain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+func setup(t *testing.T) *resolver.Resolution {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env.example"), []byte("# DATABASE_URL is documented elsewhere\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_HOST=localhost\nDATABASE_URL=postgres://${DB_USER:-app}@${DB_HOST}:$DB_PORT/db\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.local"), []byte("DATABASE_URL=postgres://dev\nDATABASE_URL=postgres://${DB_HOST}/local\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "api.env"), []byte("DB_HOST=db\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "compose.yml"), []byte(`services:
+  api:
+    env_file: api.env
+    environment:
+      DATABASE_URL: postgres://compose
+  web:
+    env_file: api.env
+`), 0644)
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	return r
+}
+
+func TestExplain_Chain(t *testing.T) {
+	r := setup(t)
+	e, err := Explain(r, "DATABASE_URL", "")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+
+	if e.FinalValue != "postgres://compose" || e.FinalFrom.Service != "api" {
+		t.Errorf("final = %s from %+v, want postgres://compose from api", e.FinalValue, e.FinalFrom)
+	}
+
+	var checked []string
+	for _, c := range e.Candidates {
+		mark := "-"
+		if c.Defined {
+			mark = "+"
+		}
+		checked = append(checked, mark+filepath.Base(c.File))
+	}
+	if got := strings.Join(checked, " "); got != "-.env.example +.env +.env.local -api.env +compose.yml" {
+		t.Errorf("candidates = %s", got)
+	}
+
+	if len(e.Definitions) != 4 {
+		t.Fatalf("got %d definitions, want 4", len(e.Definitions))
+	}
+	local := e.Definitions[1]
+	if local.Snippet != "DATABASE_URL=postgres://dev" || local.Wins {
+		t.Errorf("definition 2 = %+v", local)
+	}
+	if !e.Definitions[3].Wins || !strings.Contains(e.Definitions[0].Reason, "overridden by") {
+		t.Errorf("definitions = %+v", e.Definitions)
+	}
+
+	if len(e.Services) != 1 || e.Services[0].Service != "api" {
+		t.Errorf("services = %+v, want only api", e.Services)
+	}
+}
+
+func TestExplain_ServiceAndInterpolation(t *testing.T) {
+	r := setup(t)
+	e, err := Explain(r, "DATABASE_URL", "web")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+
+	if e.FinalValue != "postgres://${DB_HOST}/local" {
+		t.Errorf("final = %s, want the .env.local value", e.FinalValue)
+	}
+	if got := e.Definitions[1].Reason; got != "redefined later in the same file (line 2)" {
+		t.Errorf("reason = %s", got)
+	}
+	if got := e.Definitions[3].Reason; got != "applies to service api only" {
+		t.Errorf("reason = %s", got)
+	}
+
+	// web reads DB_HOST from its env_file
+	if e.Expanded != "postgres://db/local" {
+		t.Errorf("expanded = %s, want postgres://db/local", e.Expanded)
+	}
+	if len(e.Steps) != 1 || e.Steps[0].From == nil || filepath.Base(e.Steps[0].From.File) != "api.env" {
+		t.Errorf("steps = %+v", e.Steps)
+	}
+}
+
+func TestExplain_Unknown(t *testing.T) {
+	r := setup(t)
+	if _, err := Explain(r, "NOPE", ""); err == nil {
+		t.Error("Explain of an unknown variable should fail")
+	}
+}
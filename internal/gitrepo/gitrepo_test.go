@@ -0,0 +1,225 @@
+package gitrepo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// git runs the git binary in dir to build fixtures; the package itself
+// never shells out
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	gitEnv(t, dir, nil, args...)
+}
+
+func gitEnv(t *testing.T, dir string, env []string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), env...),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	return dir
+}
+
+func TestCommits_LooseAndPacked(t *testing.T) {
+	dir := newRepo(t)
+	for i := 1; i <= 5; i++ {
+		// Mostly unchanged content so gc stores deltas
+		var sb strings.Builder
+		for j := 0; j < 100; j++ {
+			fmt.Fprintf(&sb, "KEY_%d=value\n", j)
+		}
+		fmt.Fprintf(&sb, "REVISION=%d\n", i)
+		os.MkdirAll(filepath.Join(dir, "config"), 0755)
+		os.WriteFile(filepath.Join(dir, "config", ".env"), []byte(sb.String()), 0644)
+		git(t, dir, "add", ".")
+		gitEnv(t, dir, []string{fmt.Sprintf("GIT_COMMITTER_DATE=%d +0000", 1700000000+i*60)},
+			"commit", "-q", "-m", fmt.Sprintf("revision %d", i))
+	}
+
+	check := func(stage string) {
+		r, err := Open(filepath.Join(dir, "config"))
+		if err != nil {
+			t.Fatalf("%s: Open failed: %v", stage, err)
+		}
+		commits, err := r.Commits()
+		if err != nil {
+			t.Fatalf("%s: Commits failed: %v", stage, err)
+		}
+		if len(commits) != 5 {
+			t.Fatalf("%s: got %d commits, want 5", stage, len(commits))
+		}
+		if commits[0].Subject != "revision 5" {
+			t.Errorf("%s: newest commit = %q, want revision 5", stage, commits[0].Subject)
+		}
+
+		files, err := r.Files(commits[0].Tree, func(name string) bool { return name == ".env" }, nil)
+		if err != nil {
+			t.Fatalf("%s: Files failed: %v", stage, err)
+		}
+		if len(files) != 1 || files[0].Path != "config/.env" {
+			t.Fatalf("%s: Files = %v, want config/.env", stage, files)
+		}
+		data, err := r.ReadBlob(files[0].Hash)
+		if err != nil {
+			t.Fatalf("%s: ReadBlob failed: %v", stage, err)
+		}
+		if !strings.HasSuffix(string(data), "REVISION=5\n") {
+			t.Errorf("%s: blob content ends with %q", stage, data[len(data)-12:])
+		}
+	}
+
+	check("loose")
+	git(t, dir, "gc", "-q", "--aggressive")
+	check("packed")
+}
+
+func TestCommits_SkipsNonCommitRefsAndReportsCorruption(t *testing.T) {
+	dir := newRepo(t)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("A=1\n"), 0644)
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "first")
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("A=2\n"), 0644)
+	git(t, dir, "commit", "-q", "-am", "second")
+
+	// A ref to a blob, as some tools keep, is not an error
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD:.env").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "update-ref", "refs/notes/blob", strings.TrimSpace(string(out)))
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	commits, err := r.Commits()
+	if err != nil {
+		t.Fatalf("Commits failed: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(commits))
+	}
+
+	// A corrupt parent must not look like a clean, shorter history
+	loose := filepath.Join(dir, ".git", "objects", commits[1].Hash.String()[:2], commits[1].Hash.String()[2:])
+	os.Chmod(loose, 0644)
+	if err := os.WriteFile(loose, []byte("not zlib"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Commits(); err == nil {
+		t.Error("Commits should fail on a corrupt object")
+	}
+}
+
+func TestCommits_ShallowClone(t *testing.T) {
+	src := newRepo(t)
+	for i := 1; i <= 3; i++ {
+		os.WriteFile(filepath.Join(src, ".env"), []byte(fmt.Sprintf("A=%d\n", i)), 0644)
+		git(t, src, "add", ".")
+		git(t, src, "commit", "-q", "-m", fmt.Sprintf("revision %d", i))
+	}
+	dir := filepath.Join(t.TempDir(), "clone")
+	git(t, src, "clone", "-q", "--depth", "1", "file://"+src, dir)
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	commits, err := r.Commits()
+	if err != nil {
+		t.Fatalf("Commits failed: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "revision 3" {
+		t.Errorf("commits = %d, want only revision 3", len(commits))
+	}
+}
+
+func TestTrackedFiles(t *testing.T) {
+	dir := newRepo(t)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("A=1\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.local"), []byte("A=2\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "deploy"), 0755)
+	os.WriteFile(filepath.Join(dir, "deploy", "prod.env"), []byte("A=3\n"), 0644)
+	git(t, dir, "add", ".env", "deploy/prod.env")
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	tracked, err := r.TrackedFiles()
+	if err != nil {
+		t.Fatalf("TrackedFiles failed: %v", err)
+	}
+	for path, want := range map[string]bool{".env": true, "deploy/prod.env": true, ".env.local": false} {
+		if tracked[path] != want {
+			t.Errorf("tracked[%s] = %v, want %v", path, tracked[path], want)
+		}
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".git", "info"), 0755)
+	os.WriteFile(filepath.Join(dir, ".git", "info", "exclude"), []byte("*.secret\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(`# env files
+.env*
+!.env.example
+/build/
+**/private/*.env
+`), 0644)
+	os.MkdirAll(filepath.Join(dir, "app"), 0755)
+	os.WriteFile(filepath.Join(dir, "app", ".gitignore"), []byte("!.env.shared\n"), 0644)
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		".env":              true,
+		".env.local":        true,
+		".env.example":      false,
+		"app/.env":          true,
+		"app/.env.shared":   false,
+		"build/.env.prod":   true,
+		"src/build/app.env": false,
+		"a/private/db.env":  true,
+		"prod.env":          false,
+		"keys.secret":       true,
+	}
+	for path, want := range tests {
+		got, err := r.IsIgnored(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatalf("IsIgnored(%s) failed: %v", path, err)
+		}
+		if got != want {
+			t.Errorf("IsIgnored(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestOpen_NotRepository(t *testing.T) {
+	if _, err := Open(t.TempDir()); err != ErrNotRepository {
+		t.Errorf("Open = %v, want ErrNotRepository", err)
+	}
+}
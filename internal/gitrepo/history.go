@@ -0,0 +1,133 @@
+package gitrepo
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Commits returns every commit reachable from any ref, newest first. Refs
+// to trees or blobs are skipped; any other unreadable object is an error.
+// In a shallow clone, history stops at the shallow boundary
+func (r *Repo) Commits() ([]*Commit, error) {
+	refs, err := r.Refs()
+	if err != nil {
+		return nil, err
+	}
+	shallow, err := r.shallow()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[Hash]bool)
+	isRef := make(map[Hash]bool)
+	var queue []Hash
+	for _, h := range refs {
+		queue = append(queue, h)
+		isRef[h] = true
+	}
+
+	var commits []*Commit
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+
+		c, err := r.ReadCommit(h)
+		if err != nil {
+			if isRef[h] && errors.Is(err, ErrNotCommit) {
+				continue
+			}
+			return nil, fmt.Errorf("reading commit %s: %w", h.Short(), err)
+		}
+		if c.Hash != h && seen[c.Hash] {
+			continue // Tag peeled to a commit already visited
+		}
+		seen[c.Hash] = true
+		commits = append(commits, c)
+		if !shallow[c.Hash] {
+			queue = append(queue, c.Parents...)
+		}
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		if !commits[i].Time.Equal(commits[j].Time) {
+			return commits[i].Time.After(commits[j].Time)
+		}
+		return commits[i].Hash.String() < commits[j].Hash.String()
+	})
+	return commits, nil
+}
+
+// shallow reads the commits a shallow clone was cut at, whose parents are
+// not in the repository
+func (r *Repo) shallow() (map[Hash]bool, error) {
+	f, err := os.Open(filepath.Join(r.GitDir, "shallow"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	set := make(map[Hash]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h, err := ParseHash(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("shallow: %w", err)
+		}
+		set[h] = true
+	}
+	return set, scanner.Err()
+}
+
+// File is a blob at a path within a tree
+type File struct {
+	Path string
+	Hash Hash
+}
+
+// Files lists the blobs under tree whose file name satisfies match.
+// Results are memoized per subtree in cache (which may be nil), so walking
+// many commits that share directories stays cheap. A cache must only be
+// reused with the same match function
+func (r *Repo) Files(tree Hash, match func(path string) bool, cache map[Hash][]File) ([]File, error) {
+	if files, ok := cache[tree]; ok {
+		return files, nil
+	}
+
+	entries, err := r.ReadTree(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, e := range entries {
+		switch {
+		case e.IsTree():
+			sub, err := r.Files(e.Hash, match, cache)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range sub {
+				files = append(files, File{Path: path.Join(e.Name, f.Path), Hash: f.Hash})
+			}
+		case e.IsBlob() && match(e.Name):
+			files = append(files, File{Path: e.Name, Hash: e.Hash})
+		}
+	}
+
+	if cache != nil {
+		cache[tree] = files
+	}
+	return files, nil
+}
@@ -0,0 +1,148 @@
+package gitrepo
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one line of a .gitignore file
+type ignoreRule struct {
+	base    string // Directory of the .gitignore, relative to the work tree
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// IsIgnored reports whether path is excluded by .gitignore files (from the
+// work tree root down to the file's directory) or .git/info/exclude. The
+// global core.excludesFile is not consulted
+func (r *Repo) IsIgnored(p string) (bool, error) {
+	rel, err := r.RelPath(p)
+	if err != nil {
+		return false, err
+	}
+
+	rules := r.loadIgnore("", filepath.Join(r.GitDir, "info", "exclude"))
+	rules = append(rules, r.loadIgnore("", filepath.Join(r.WorkTree, ".gitignore"))...)
+
+	parts := strings.Split(rel, "/")
+	for i := 0; i < len(parts)-1; i++ {
+		dir := strings.Join(parts[:i+1], "/")
+		// A file under an excluded directory can't be re-included
+		if matchIgnore(rules, dir, true) {
+			return true, nil
+		}
+		rules = append(rules, r.loadIgnore(dir, filepath.Join(r.WorkTree, filepath.FromSlash(dir), ".gitignore"))...)
+	}
+	return matchIgnore(rules, rel, false), nil
+}
+
+// matchIgnore applies rules in order; the last match decides
+func matchIgnore(rules []ignoreRule, rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(rel, rule.base+"/")
+		}
+		if rule.re.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r *Repo) loadIgnore(base, file string) []ignoreRule {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	var rules []ignoreRule
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if line[0] == '!' {
+			rule.negate = true
+			line = line[1:]
+		} else if line[0] == '\\' {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		// A slash anywhere but the end anchors the pattern to base
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// globToRegexp translates gitignore wildcards, including "**"
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package gitrepo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TrackedFiles returns the paths recorded in the index, relative to the
+// work tree and slash separated. A repository without an index tracks
+// nothing
+func (r *Repo) TrackedFiles() (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "index"))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIndex(data)
+}
+
+// IsTracked reports whether path is in the index
+func (r *Repo) IsTracked(path string) (bool, error) {
+	rel, err := r.RelPath(path)
+	if err != nil {
+		return false, err
+	}
+	tracked, err := r.TrackedFiles()
+	if err != nil {
+		return false, err
+	}
+	return tracked[rel], nil
+}
+
+// Size of the fixed part of an index entry, up to and including flags
+const indexEntryHeader = 62
+
+func parseIndex(data []byte) (map[string]bool, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], []byte("DIRC")) {
+		return nil, errors.New("malformed index")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	paths := make(map[string]bool, count)
+	pos := 12
+	var prev []byte
+	for i := 0; i < count; i++ {
+		start := pos
+		if len(data) < pos+indexEntryHeader {
+			return nil, errors.New("truncated index")
+		}
+		flags := binary.BigEndian.Uint16(data[pos+60:])
+		pos += indexEntryHeader
+		if version >= 3 && flags&0x4000 != 0 {
+			pos += 2 // Extended flags
+		}
+
+		var name []byte
+		if version == 4 {
+			// Paths are prefix-compressed against the previous entry
+			strip, n := indexVarint(data[pos:])
+			pos += n
+			nul := bytes.IndexByte(data[pos:], 0)
+			if nul < 0 || strip > len(prev) {
+				return nil, errors.New("truncated index")
+			}
+			name = append(append([]byte{}, prev[:len(prev)-strip]...), data[pos:pos+nul]...)
+			pos += nul + 1
+		} else {
+			nul := bytes.IndexByte(data[pos:], 0)
+			if nul < 0 {
+				return nil, errors.New("truncated index")
+			}
+			name = data[pos : pos+nul]
+			// Entries are NUL padded to a multiple of eight bytes
+			pos = start + (pos+nul-start+8)&^7
+		}
+
+		paths[string(name)] = true
+		prev = name
+	}
+	return paths, nil
+}
+
+// indexVarint decodes the offset encoding used by index v4 (the same as
+// OFS_DELTA in packs)
+func indexVarint(b []byte) (int, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	c := b[0]
+	v := int(c & 0x7f)
+	i := 1
+	for c&0x80 != 0 && i < len(b) {
+		c = b[i]
+		i++
+		v = ((v + 1) << 7) | int(c&0x7f)
+	}
+	return v, i
+}
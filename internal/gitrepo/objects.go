@@ -0,0 +1,150 @@
+package gitrepo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotCommit is returned by ReadCommit for objects that are not commits
+// or tags pointing at one
+var ErrNotCommit = errors.New("not a commit")
+
+// Commit is the subset of a commit object needed to walk history
+type Commit struct {
+	Hash    Hash
+	Tree    Hash
+	Parents []Hash
+	Time    time.Time // Committer time
+	Subject string    // First line of the message
+}
+
+// ReadCommit loads and parses a commit, peeling annotated tags
+func (r *Repo) ReadCommit(h Hash) (*Commit, error) {
+	for i := 0; i < maxDeltaDepth; i++ {
+		typ, data, err := r.ReadObject(h)
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case TypeCommit:
+			return parseCommit(h, data)
+		case TypeTag:
+			target, ok := header(data, "object")
+			if !ok {
+				return nil, fmt.Errorf("tag %s has no object", h)
+			}
+			if h, err = ParseHash(target); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%s is a %s: %w", h, typ, ErrNotCommit)
+		}
+	}
+	return nil, fmt.Errorf("tag chain too deep at %s", h)
+}
+
+func parseCommit(h Hash, data []byte) (*Commit, error) {
+	c := &Commit{Hash: h}
+	headers, message, _ := bytes.Cut(data, []byte("\n\n"))
+
+	for _, line := range strings.Split(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			tree, err := ParseHash(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Tree = tree
+		case "parent":
+			parent, err := ParseHash(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Parents = append(c.Parents, parent)
+		case "committer":
+			c.Time = signatureTime(value)
+		}
+	}
+
+	subject, _, _ := strings.Cut(string(message), "\n")
+	c.Subject = strings.TrimSpace(subject)
+	return c, nil
+}
+
+// signatureTime reads "Name <email> 1700000000 +0100"
+func signatureTime(sig string) time.Time {
+	fields := strings.Fields(sig[strings.LastIndexByte(sig, '>')+1:])
+	if len(fields) == 0 {
+		return time.Time{}
+	}
+	secs, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0).UTC()
+}
+
+func header(data []byte, key string) (string, bool) {
+	headers, _, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range strings.Split(string(headers), "\n") {
+		if k, v, ok := strings.Cut(line, " "); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// TreeEntry is one entry of a tree object
+type TreeEntry struct {
+	Name string
+	Mode string
+	Hash Hash
+}
+
+// IsTree reports whether the entry is a subdirectory
+func (e TreeEntry) IsTree() bool { return e.Mode == "40000" }
+
+// IsBlob reports whether the entry is a regular file or symlink
+func (e TreeEntry) IsBlob() bool { return e.Mode != "40000" && e.Mode != "160000" }
+
+// ReadTree loads the entries of a tree object
+func (r *Repo) ReadTree(h Hash) ([]TreeEntry, error) {
+	typ, data, err := r.ReadObject(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TypeTree {
+		return nil, fmt.Errorf("%s is a %s, not a tree", h, typ)
+	}
+
+	var entries []TreeEntry
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+1+len(Hash{}) {
+			return nil, fmt.Errorf("malformed tree %s", h)
+		}
+		e := TreeEntry{Mode: string(data[:sp]), Name: string(data[sp+1 : nul])}
+		copy(e.Hash[:], data[nul+1:])
+		entries = append(entries, e)
+		data = data[nul+1+len(Hash{}):]
+	}
+	return entries, nil
+}
+
+// ReadBlob loads file content
+func (r *Repo) ReadBlob(h Hash) ([]byte, error) {
+	typ, data, err := r.ReadObject(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TypeBlob {
+		return nil, fmt.Errorf("%s is a %s, not a blob", h, typ)
+	}
+	return data, nil
+}
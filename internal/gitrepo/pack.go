@@ -0,0 +1,289 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Packed object types
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+var packTypeNames = map[int]string{
+	packCommit: TypeCommit,
+	packTree:   TypeTree,
+	packBlob:   TypeBlob,
+	packTag:    TypeTag,
+}
+
+// pack is a packfile with its version 2 index loaded into memory
+type pack struct {
+	path    string
+	hashes  []Hash
+	offsets []int64
+}
+
+func (r *Repo) loadPacks() error {
+	idxFiles, _ := filepath.Glob(filepath.Join(r.GitDir, "objects", "pack", "*.idx"))
+	sort.Strings(idxFiles)
+	for _, idx := range idxFiles {
+		p, err := loadIndex(idx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(idx), err)
+		}
+		r.packs = append(r.packs, p)
+	}
+	return nil
+}
+
+func loadIndex(path string) (*pack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], []byte("\xfftOc")) {
+		return nil, errors.New("unsupported pack index (only version 2 is read)")
+	}
+	if v := binary.BigEndian.Uint32(data[4:8]); v != 2 {
+		return nil, fmt.Errorf("unsupported pack index version %d", v)
+	}
+
+	fanout := data[8 : 8+256*4]
+	n := int(binary.BigEndian.Uint32(fanout[255*4:]))
+
+	hashStart := 8 + 256*4
+	crcStart := hashStart + n*20
+	offStart := crcStart + n*4
+	largeStart := offStart + n*4
+	if len(data) < largeStart {
+		return nil, errors.New("truncated pack index")
+	}
+
+	p := &pack{
+		path:    strings.TrimSuffix(path, ".idx") + ".pack",
+		hashes:  make([]Hash, n),
+		offsets: make([]int64, n),
+	}
+	for i := 0; i < n; i++ {
+		copy(p.hashes[i][:], data[hashStart+i*20:])
+		off := binary.BigEndian.Uint32(data[offStart+i*4:])
+		if off&0x80000000 != 0 {
+			j := int(off & 0x7fffffff)
+			p.offsets[i] = int64(binary.BigEndian.Uint64(data[largeStart+j*8:]))
+		} else {
+			p.offsets[i] = int64(off)
+		}
+	}
+	return p, nil
+}
+
+func (p *pack) find(h Hash) (int64, bool) {
+	i := sort.Search(len(p.hashes), func(i int) bool {
+		return bytes.Compare(p.hashes[i][:], h[:]) >= 0
+	})
+	if i < len(p.hashes) && p.hashes[i] == h {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// readAt decodes the object stored at offset, resolving deltas
+func (p *pack) readAt(r *Repo, offset int64) (string, []byte, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	return p.decode(r, f, offset, 0)
+}
+
+// maxDeltaDepth guards against corrupt packs with cyclic deltas
+const maxDeltaDepth = 64
+
+func (p *pack) decode(r *Repo, f *os.File, offset int64, depth int) (string, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, errors.New("delta chain too deep")
+	}
+
+	br := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+	typ, size, err := readEntryHeader(br)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch typ {
+	case packCommit, packTree, packBlob, packTag:
+		data, err := inflate(br, size)
+		return packTypeNames[typ], data, err
+
+	case packOfsDelta:
+		rel, err := readOfsOffset(br)
+		if err != nil {
+			return "", nil, err
+		}
+		delta, err := inflate(br, size)
+		if err != nil {
+			return "", nil, err
+		}
+		baseType, base, err := p.decode(r, f, offset-rel, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+
+	case packRefDelta:
+		var baseHash Hash
+		if _, err := io.ReadFull(br, baseHash[:]); err != nil {
+			return "", nil, err
+		}
+		delta, err := inflate(br, size)
+		if err != nil {
+			return "", nil, err
+		}
+		baseType, base, err := r.ReadObject(baseHash)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := applyDelta(base, delta)
+		return baseType, data, err
+	}
+
+	return "", nil, fmt.Errorf("unknown pack object type %d", typ)
+}
+
+func readEntryHeader(br *bufio.Reader) (int, int64, error) {
+	c, err := br.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	typ := int(c>>4) & 7
+	size := int64(c & 0x0f)
+	shift := uint(4)
+	for c&0x80 != 0 {
+		if c, err = br.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= int64(c&0x7f) << shift
+		shift += 7
+	}
+	return typ, size, nil
+}
+
+// readOfsOffset decodes the base distance of an OFS_DELTA entry
+func readOfsOffset(br *bufio.Reader) (int64, error) {
+	c, err := br.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	off := int64(c & 0x7f)
+	for c&0x80 != 0 {
+		if c, err = br.ReadByte(); err != nil {
+			return 0, err
+		}
+		off = ((off + 1) << 7) | int64(c&0x7f)
+	}
+	return off, nil
+}
+
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, n := deltaSize(delta)
+	delta = delta[n:]
+	dstSize, n := deltaSize(delta)
+	delta = delta[n:]
+	if srcSize != len(base) {
+		return nil, errors.New("delta base size mismatch")
+	}
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 != 0 {
+			var off, size int
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errors.New("truncated delta")
+					}
+					off |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(1<<(4+i)) != 0 {
+					if len(delta) == 0 {
+						return nil, errors.New("truncated delta")
+					}
+					size |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if off+size > len(base) {
+				return nil, errors.New("delta copy out of range")
+			}
+			out = append(out, base[off:off+size]...)
+			continue
+		}
+
+		if op == 0 {
+			return nil, errors.New("invalid delta opcode")
+		}
+		if int(op) > len(delta) {
+			return nil, errors.New("truncated delta")
+		}
+		out = append(out, delta[:op]...)
+		delta = delta[op:]
+	}
+
+	if len(out) != dstSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+	return out, nil
+}
+
+func deltaSize(b []byte) (int, int) {
+	size, shift, i := 0, uint(0), 0
+	for i < len(b) {
+		c := b[i]
+		i++
+		size |= int(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			break
+		}
+	}
+	return size, i
+}
@@ -0,0 +1,239 @@
+// Package gitrepo reads a local git repository directly from its .git
+// directory. It supports just what envmerge needs: refs, commits, trees,
+// blobs (loose and packed), the index and .gitignore rules
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepository is returned when no .git directory is found
+var ErrNotRepository = errors.New("not a git repository")
+
+// Hash is a SHA-1 object ID
+type Hash [20]byte
+
+func (h Hash) String() string { return hex.EncodeToString(h[:]) }
+
+// Short returns the abbreviated hash shown in reports
+func (h Hash) Short() string { return h.String()[:8] }
+
+// ParseHash decodes a 40-character hex object ID
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	b, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != len(h) {
+		return h, fmt.Errorf("invalid object id %q", s)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// Object types as stored by git
+const (
+	TypeCommit = "commit"
+	TypeTree   = "tree"
+	TypeBlob   = "blob"
+	TypeTag    = "tag"
+)
+
+// Repo is an opened repository
+type Repo struct {
+	GitDir   string // The .git directory
+	WorkTree string // Directory containing .git
+
+	packs []*pack
+}
+
+// Open finds the repository containing path by walking up to the nearest
+// .git directory
+func Open(path string) (*Repo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := abs
+	for {
+		candidate := filepath.Join(dir, ".git")
+		info, err := os.Stat(candidate)
+		if err == nil {
+			gitDir := candidate
+			if !info.IsDir() {
+				// Worktrees and submodules use a "gitdir: <path>" file
+				if gitDir, err = readGitFile(candidate); err != nil {
+					return nil, err
+				}
+			}
+			r := &Repo{GitDir: gitDir, WorkTree: dir}
+			if err := r.loadPacks(); err != nil {
+				return nil, err
+			}
+			return r, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrNotRepository
+		}
+		dir = parent
+	}
+}
+
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("%s: unexpected contents", path)
+	}
+	dir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return dir, nil
+}
+
+// RelPath returns path relative to the work tree, slash separated
+func (r *Repo) RelPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(r.WorkTree, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// ReadObject returns the type and content of an object
+func (r *Repo) ReadObject(h Hash) (string, []byte, error) {
+	hex := h.String()
+	path := filepath.Join(r.GitDir, "objects", hex[:2], hex[2:])
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		return readLoose(f)
+	}
+
+	for _, p := range r.packs {
+		if offset, ok := p.find(h); ok {
+			return p.readAt(r, offset)
+		}
+	}
+	return "", nil, fmt.Errorf("object %s not found", hex)
+}
+
+func readLoose(f io.Reader) (string, []byte, error) {
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, err
+	}
+	nul := bytes.IndexByte(data, 0)
+	if nul < 0 {
+		return "", nil, errors.New("malformed loose object")
+	}
+	typ, _, _ := strings.Cut(string(data[:nul]), " ")
+	return typ, data[nul+1:], nil
+}
+
+// Refs returns every ref (branches, remotes, tags) plus HEAD, mapped to
+// the object it points at
+func (r *Repo) Refs() (map[string]Hash, error) {
+	refs := make(map[string]Hash)
+
+	// packed-refs first so loose refs take precedence
+	if f, err := os.Open(filepath.Join(r.GitDir, "packed-refs")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" || line[0] == '#' || line[0] == '^' {
+				continue
+			}
+			id, name, ok := strings.Cut(line, " ")
+			if h, err := ParseHash(id); ok && err == nil {
+				refs[name] = h
+			}
+		}
+		f.Close()
+	}
+
+	refsDir := filepath.Join(r.GitDir, "refs")
+	filepath.WalkDir(refsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		if h, err := ParseHash(string(data)); err == nil {
+			rel, _ := filepath.Rel(r.GitDir, path)
+			refs[filepath.ToSlash(rel)] = h
+		}
+		return nil
+	})
+
+	if h, err := r.Head(); err == nil {
+		refs["HEAD"] = h
+	}
+	return refs, nil
+}
+
+// Head resolves HEAD to a commit
+func (r *Repo) Head() (Hash, error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "HEAD"))
+	if err != nil {
+		return Hash{}, err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "ref:") {
+		return ParseHash(line)
+	}
+
+	name := strings.TrimSpace(strings.TrimPrefix(line, "ref:"))
+	if data, err := os.ReadFile(filepath.Join(r.GitDir, filepath.FromSlash(name))); err == nil {
+		return ParseHash(string(data))
+	}
+	refs, _ := r.packedRefs()
+	if h, ok := refs[name]; ok {
+		return h, nil
+	}
+	return Hash{}, fmt.Errorf("HEAD points to missing ref %s", name)
+}
+
+func (r *Repo) packedRefs() (map[string]Hash, error) {
+	refs := make(map[string]Hash)
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "packed-refs"))
+	if err != nil {
+		return refs, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		if id, name, ok := strings.Cut(line, " "); ok {
+			if h, err := ParseHash(id); err == nil {
+				refs[name] = h
+			}
+		}
+	}
+	return refs, nil
+}
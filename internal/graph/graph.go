@@ -0,0 +1,217 @@
+// Package graph builds a graph of env files, layers, variables and compose
+// services from a resolution, and renders it as DOT or Mermaid
+package graph
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// NodeKind is what a node stands for
+type NodeKind int
+
+const (
+	NodeFile NodeKind = iota
+	NodeVariable
+	NodeService
+)
+
+// Node is a file, variable or service
+type Node struct {
+	ID      string
+	Label   string
+	Kind    NodeKind
+	Missing bool // A variable that is referenced but never defined
+}
+
+// EdgeKind is how two nodes are related
+type EdgeKind int
+
+const (
+	EdgeDefines    EdgeKind = iota // File sets a variable
+	EdgeReceives                   // Variable is passed to a service
+	EdgeEnvFile                    // Service reads a file through env_file
+	EdgeReferences                 // Variable's value refers to another variable
+)
+
+// Edge connects two nodes by ID
+type Edge struct {
+	From  string
+	To    string
+	Kind  EdgeKind
+	Label string
+	Wins  bool // For EdgeDefines: this definition is the final value
+}
+
+// LayerGroup holds the file nodes of one precedence layer
+type LayerGroup struct {
+	Layer resolver.Layer
+	Files []Node
+}
+
+// Graph is the full picture, with layers in precedence order
+type Graph struct {
+	Layers    []LayerGroup
+	Variables []Node
+	Services  []Node
+	Edges     []Edge
+}
+
+// Build creates the graph for a resolution. Values are never included
+func Build(r *resolver.Resolution) *Graph {
+	g := &Graph{}
+	b := builder{r: r, g: g, files: make(map[string]string), vars: make(map[string]string), seen: make(map[Edge]int)}
+
+	for _, f := range r.EnvFiles {
+		b.file(f, layerOf(r, f))
+	}
+	for _, v := range r.Variables {
+		for _, src := range v.Chain {
+			if src.Layer == resolver.LayerComposeEnvFile {
+				b.file(src.File, src.Layer)
+			}
+		}
+	}
+	for _, f := range r.ComposeFiles {
+		b.file(f, resolver.LayerComposeInline)
+	}
+	sort.SliceStable(g.Layers, func(i, j int) bool {
+		return g.Layers[i].Layer.Precedence() < g.Layers[j].Layer.Precedence()
+	})
+
+	services := make(map[string]string)
+	for i, svc := range r.Services() {
+		id := fmt.Sprintf("svc_%d", i)
+		services[svc] = id
+		g.Services = append(g.Services, Node{ID: id, Label: svc, Kind: NodeService})
+	}
+
+	for _, v := range r.Variables {
+		b.variable(v.Name)
+	}
+
+	for _, v := range r.Variables {
+		id := b.vars[v.Name]
+		last := len(v.Chain) - 1
+		received := make(map[string]bool)
+		for i, src := range v.Chain {
+			label := ""
+			if src.Line > 0 {
+				label = fmt.Sprintf("line %d", src.Line)
+			}
+			b.edge(Edge{From: b.file(sourceFile(src), src.Layer), To: id, Kind: EdgeDefines, Label: label, Wins: i == last})
+
+			if src.Service != "" && !received[src.Service] {
+				received[src.Service] = true
+				b.edge(Edge{From: id, To: services[src.Service], Kind: EdgeReceives})
+			}
+			if src.Layer == resolver.LayerComposeEnvFile {
+				b.edge(Edge{From: b.files[src.File], To: services[src.Service], Kind: EdgeEnvFile, Label: "env_file"})
+			}
+
+			var steps []resolver.Expansion
+			if src.IsInline {
+				steps, _ = r.InterpolateCompose(src.Value)
+			} else {
+				steps, _ = r.Interpolate(src.Value, src.Service)
+			}
+			for _, step := range steps {
+				b.edge(Edge{From: id, To: b.variable(step.Name), Kind: EdgeReferences, Label: "uses"})
+			}
+		}
+	}
+	return g
+}
+
+type builder struct {
+	r     *resolver.Resolution
+	g     *Graph
+	files map[string]string // Path to node ID
+	vars  map[string]string // Name to node ID
+	seen  map[Edge]int      // Edge without Wins to its index
+}
+
+// file returns the node ID for path, adding it to its layer on first use
+func (b *builder) file(path string, layer resolver.Layer) string {
+	if id, ok := b.files[path]; ok {
+		return id
+	}
+	id := fmt.Sprintf("file_%d", len(b.files))
+	b.files[path] = id
+
+	node := Node{ID: id, Label: b.label(path), Kind: NodeFile}
+	for i := range b.g.Layers {
+		if b.g.Layers[i].Layer == layer {
+			b.g.Layers[i].Files = append(b.g.Layers[i].Files, node)
+			return id
+		}
+	}
+	b.g.Layers = append(b.g.Layers, LayerGroup{Layer: layer, Files: []Node{node}})
+	return id
+}
+
+// variable returns the node ID for name, adding a missing node for names
+// that are only referenced
+func (b *builder) variable(name string) string {
+	if id, ok := b.vars[name]; ok {
+		return id
+	}
+	id := fmt.Sprintf("var_%d", len(b.vars))
+	b.vars[name] = id
+	_, defined := b.r.ByName[name]
+	b.g.Variables = append(b.g.Variables, Node{ID: id, Label: name, Kind: NodeVariable, Missing: !defined})
+	return id
+}
+
+// edge adds e unless the same edge exists; a shared env_file yields one
+// source per service, and any of them may be the winner
+func (b *builder) edge(e Edge) {
+	key := e
+	key.Wins = false
+	if i, ok := b.seen[key]; ok {
+		b.g.Edges[i].Wins = b.g.Edges[i].Wins || e.Wins
+		return
+	}
+	b.seen[key] = len(b.g.Edges)
+	b.g.Edges = append(b.g.Edges, e)
+}
+
+// label shows path relative to the scanned directory
+func (b *builder) label(path string) string {
+	if rel, err := filepath.Rel(b.r.Path, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// sourceFile names the file of a source; OS environment values have none
+func sourceFile(src resolver.Source) string {
+	if src.File == "" {
+		return src.Layer.String()
+	}
+	return src.File
+}
+
+// layerOf finds the layer an env file was read as
+func layerOf(r *resolver.Resolution, path string) resolver.Layer {
+	for _, v := range r.Variables {
+		for _, src := range v.Chain {
+			if src.File == path {
+				return src.Layer
+			}
+		}
+	}
+	switch filepath.Base(path) {
+	case ".env.example":
+		return resolver.LayerEnvExample
+	case ".env":
+		return resolver.LayerEnv
+	case ".env.local":
+		return resolver.LayerEnvLocal
+	default:
+		return resolver.LayerEnvOther
+	}
+}
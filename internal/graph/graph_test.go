@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+func setup(t *testing.T) *Graph {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_HOST=localhost\nDATABASE_URL=postgres://${DB_HOST}:$DB_PORT/db\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "api.env"), []byte("DB_HOST=db\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "compose.yml"), []byte(`services:
+  api:
+    env_file: api.env
+  web:
+    env_file: api.env
+`), 0644)
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	return Build(r)
+}
+
+// edges renders edges of one kind as "from -> to" using node labels
+func edges(g *Graph, kind EdgeKind) []string {
+	labels := make(map[string]string)
+	for _, l := range g.Layers {
+		for _, f := range l.Files {
+			labels[f.ID] = f.Label
+		}
+	}
+	for _, n := range append(g.Variables, g.Services...) {
+		labels[n.ID] = n.Label
+	}
+
+	var out []string
+	for _, e := range g.Edges {
+		if e.Kind != kind {
+			continue
+		}
+		s := labels[e.From] + " -> " + labels[e.To]
+		if e.Wins {
+			s += " (wins)"
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestBuild_Edges(t *testing.T) {
+	g := setup(t)
+
+	var layers []string
+	for _, l := range g.Layers {
+		layers = append(layers, l.Layer.String())
+	}
+	if got := strings.Join(layers, ", "); got != ".env, compose env_file, compose inline" {
+		t.Errorf("layers = %s", got)
+	}
+
+	// The shared env_file defines DB_HOST once, not once per service
+	if got := strings.Join(edges(g, EdgeDefines), "; "); got != ".env -> DATABASE_URL (wins); .env -> DB_HOST; api.env -> DB_HOST (wins)" {
+		t.Errorf("defines = %s", got)
+	}
+	if got := strings.Join(edges(g, EdgeEnvFile), "; "); got != "api.env -> api; api.env -> web" {
+		t.Errorf("env_file = %s", got)
+	}
+	if got := strings.Join(edges(g, EdgeReferences), "; "); got != "DATABASE_URL -> DB_HOST; DATABASE_URL -> DB_PORT" {
+		t.Errorf("references = %s", got)
+	}
+
+	missing := g.Variables[len(g.Variables)-1]
+	if missing.Label != "DB_PORT" || !missing.Missing {
+		t.Errorf("last variable = %+v, want missing DB_PORT", missing)
+	}
+}
+
+func TestFormat_DOTAndMermaid(t *testing.T) {
+	g := setup(t)
+
+	dot := FormatDOT(g)
+	for _, want := range []string{"digraph envmerge {", `subgraph cluster_layer_1 {`, `[label="api.env", shape=note]`, `penwidth=2`, `style=dotted`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %s", want)
+		}
+	}
+
+	mermaid := FormatMermaid(g)
+	for _, want := range []string{"flowchart LR", `subgraph layer_1 ["compose env_file"]`, `==>|"line 1"|`, "class var_2 missing"} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid missing %s", want)
+		}
+	}
+}
+
+func TestQuote_Escaping(t *testing.T) {
+	if got := dotQuote(`a "b" \c`); got != `"a \"b\" \\c"` {
+		t.Errorf("dotQuote = %s", got)
+	}
+	if got := mermaidQuote(`say "hi"`); got != `"say #quot;hi#quot;"` {
+		t.Errorf("mermaidQuote = %s", got)
+	}
+}
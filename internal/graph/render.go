@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Edge colors shared by both formats
+const (
+	winColor  = "#1a7f37"
+	loseColor = "#8c959f"
+	refColor  = "#0969da"
+	badColor  = "#cf222e"
+)
+
+// FormatDOT renders the graph for Graphviz. Layers are clusters of files;
+// winning definitions are bold and green, overridden ones dashed and grey
+func FormatDOT(g *Graph) string {
+	var sb strings.Builder
+	sb.WriteString("digraph envmerge {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [fontname=\"Helvetica\", fontsize=11];\n")
+	sb.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n\n")
+
+	for i, layer := range g.Layers {
+		sb.WriteString(fmt.Sprintf("  subgraph cluster_layer_%d {\n", i))
+		sb.WriteString(fmt.Sprintf("    label=%s;\n    style=\"rounded,dashed\";\n", dotQuote(layer.Layer.String())))
+		for _, f := range layer.Files {
+			sb.WriteString(fmt.Sprintf("    %s [label=%s, shape=note];\n", f.ID, dotQuote(f.Label)))
+		}
+		sb.WriteString("  }\n")
+	}
+	sb.WriteString("\n")
+
+	for _, v := range g.Variables {
+		attrs := "shape=box, style=rounded"
+		if v.Missing {
+			attrs = fmt.Sprintf("shape=box, style=\"rounded,dashed\", color=%s, fontcolor=%s", dotQuote(badColor), dotQuote(badColor))
+		}
+		sb.WriteString(fmt.Sprintf("  %s [label=%s, %s];\n", v.ID, dotQuote(v.Label), attrs))
+	}
+	for _, s := range g.Services {
+		sb.WriteString(fmt.Sprintf("  %s [label=%s, shape=component];\n", s.ID, dotQuote(s.Label)))
+	}
+	sb.WriteString("\n")
+
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Label))
+		}
+		switch e.Kind {
+		case EdgeDefines:
+			if e.Wins {
+				attrs = append(attrs, "color="+dotQuote(winColor), "penwidth=2")
+			} else {
+				attrs = append(attrs, "color="+dotQuote(loseColor), "style=dashed")
+			}
+		case EdgeEnvFile:
+			attrs = append(attrs, "style=dotted")
+		case EdgeReferences:
+			attrs = append(attrs, "color="+dotQuote(refColor), "style=dashed")
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s", e.From, e.To))
+		if len(attrs) > 0 {
+			sb.WriteString(" [" + strings.Join(attrs, ", ") + "]")
+		}
+		sb.WriteString(";\n")
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// FormatMermaid renders the graph as a Mermaid flowchart. Layers are
+// subgraphs of files; winning definitions are thick, overridden ones dotted
+func FormatMermaid(g *Graph) string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	for i, layer := range g.Layers {
+		sb.WriteString(fmt.Sprintf("  subgraph layer_%d [%s]\n", i, mermaidQuote(layer.Layer.String())))
+		for _, f := range layer.Files {
+			sb.WriteString(fmt.Sprintf("    %s[%s]\n", f.ID, mermaidQuote(f.Label)))
+		}
+		sb.WriteString("  end\n")
+	}
+
+	var missing []string
+	for _, v := range g.Variables {
+		sb.WriteString(fmt.Sprintf("  %s(%s)\n", v.ID, mermaidQuote(v.Label)))
+		if v.Missing {
+			missing = append(missing, v.ID)
+		}
+	}
+	for _, s := range g.Services {
+		sb.WriteString(fmt.Sprintf("  %s[[%s]]\n", s.ID, mermaidQuote(s.Label)))
+	}
+
+	var wins, losses, refs []string
+	for i, e := range g.Edges {
+		arrow := "-->"
+		switch {
+		case e.Kind == EdgeDefines && e.Wins:
+			arrow = "==>"
+			wins = append(wins, fmt.Sprint(i))
+		case e.Kind == EdgeDefines:
+			arrow = "-.->"
+			losses = append(losses, fmt.Sprint(i))
+		case e.Kind == EdgeEnvFile:
+			arrow = "-.->"
+		case e.Kind == EdgeReferences:
+			arrow = "-.->"
+			refs = append(refs, fmt.Sprint(i))
+		}
+		if e.Label != "" {
+			arrow += "|" + mermaidQuote(e.Label) + "|"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s\n", e.From, arrow, e.To))
+	}
+
+	if len(wins) > 0 {
+		sb.WriteString(fmt.Sprintf("  linkStyle %s stroke:%s,stroke-width:2px\n", strings.Join(wins, ","), winColor))
+	}
+	if len(losses) > 0 {
+		sb.WriteString(fmt.Sprintf("  linkStyle %s stroke:%s\n", strings.Join(losses, ","), loseColor))
+	}
+	if len(refs) > 0 {
+		sb.WriteString(fmt.Sprintf("  linkStyle %s stroke:%s\n", strings.Join(refs, ","), refColor))
+	}
+	if len(missing) > 0 {
+		sb.WriteString(fmt.Sprintf("  classDef missing stroke:%s,stroke-dasharray:4,color:%s\n", badColor, badColor))
+		sb.WriteString(fmt.Sprintf("  class %s missing\n", strings.Join(missing, ",")))
+	}
+	return sb.String()
+}
+
+// dotQuote makes s a DOT string literal
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidQuote makes s a quoted Mermaid label; quotes become entity codes
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,76 @@
+package lint
+
+import (
+	"os"
+)
+
+// Change is the edit made to one file by Fix
+type Change struct {
+	Path   string
+	Before string
+	After  string
+	Fixed  int // Number of diagnostics addressed
+}
+
+// Fix runs the fixer of every rule that has fixable diagnostics and
+// returns the files whose content changed. Edits are made to the parsed
+// documents in ctx; nothing is written to disk
+func Fix(ctx *Context, diags []Diagnostic) []Change {
+	before := make(map[string]string, len(ctx.Files))
+	for _, f := range ctx.Files {
+		before[f.Path] = f.Doc.String()
+	}
+
+	byRule := make(map[string][]Diagnostic)
+	fixedPerFile := make(map[string]int)
+	for _, d := range diags {
+		if d.Fixable {
+			byRule[d.RuleID] = append(byRule[d.RuleID], d)
+			fixedPerFile[d.Location.File]++
+		}
+	}
+
+	for _, rule := range Rules() {
+		if rule.Fix != nil && len(byRule[rule.ID]) > 0 {
+			rule.Fix(ctx, byRule[rule.ID])
+		}
+	}
+
+	var changes []Change
+	for _, f := range ctx.Files {
+		after := f.Doc.String()
+		if after != before[f.Path] {
+			changes = append(changes, Change{
+				Path:   f.Path,
+				Before: before[f.Path],
+				After:  after,
+				Fixed:  fixedPerFile[f.Path],
+			})
+		}
+	}
+	return changes
+}
+
+// Write saves each changed file, keeping its permissions
+func Write(changes []Change) error {
+	for _, c := range changes {
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(c.Path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(c.Path, []byte(c.After), mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// file returns the context file at path, or nil
+func (ctx *Context) file(path string) *File {
+	for _, f := range ctx.Files {
+		if f.Path == path {
+			return f
+		}
+	}
+	return nil
+}
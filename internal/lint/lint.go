@@ -0,0 +1,341 @@
+// Package lint runs registered rules over a project's env files and resolution
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/config"
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+	"github.com/stackgen-cli/envmerge/internal/gitrepo"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+)
+
+// Severity ranks how serious a diagnostic is
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSeverity maps a config value to a Severity. ok is false for "off"
+func ParseSeverity(s string) (sev Severity, ok bool, err error) {
+	switch strings.ToLower(s) {
+	case "error":
+		return SeverityError, true, nil
+	case "warning", "warn":
+		return SeverityWarning, true, nil
+	case "info":
+		return SeverityInfo, true, nil
+	case "off", "false", "none":
+		return 0, false, nil
+	default:
+		return 0, false, fmt.Errorf("unknown severity %q", s)
+	}
+}
+
+// Location points at a position in a file. Line and Column are 1-based;
+// zero means unknown
+type Location struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (l Location) String() string {
+	switch {
+	case l.Line > 0 && l.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+	case l.Line > 0:
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	default:
+		return l.File
+	}
+}
+
+// Diagnostic is a single rule finding
+type Diagnostic struct {
+	RuleID   string
+	Severity Severity
+	Message  string
+	Location Location
+	Variable string // Variable the finding is about, if any
+	Fixable  bool   // The rule's fixer can repair this finding
+}
+
+// File is a parsed env file handed to rules
+type File struct {
+	Path  string
+	Layer resolver.Layer
+	Doc   *dotenv.Document
+
+	InRepo  bool // Inside a git work tree; Tracked and Ignored are only set then
+	Tracked bool // In the git index
+	Ignored bool // Matched by .gitignore
+}
+
+// Context is everything a rule may inspect
+type Context struct {
+	Path       string
+	Resolution *resolver.Resolution
+	Schema     *schema.Schema // May be nil
+	Files      []*File
+}
+
+// NewContext parses every env file the resolution read
+func NewContext(r *resolver.Resolution, s *schema.Schema) (*Context, error) {
+	ctx := &Context{Path: r.Path, Resolution: r, Schema: s}
+
+	// SOPS files are written by the tool, not by hand. Files with dotenvx
+	// values are ordinary .env files and are linted like any other
+	seen := make(map[string]bool)
+	for _, f := range r.EncryptedFiles {
+		if f.IsSOPS() {
+			seen[f.File] = true
+		}
+	}
+	add := func(path string, layer resolver.Layer) error {
+		if seen[path] {
+			return nil
+		}
+		seen[path] = true
+		doc, err := dotenv.ParseFile(path)
+		if err != nil {
+			return err
+		}
+		ctx.Files = append(ctx.Files, &File{Path: path, Layer: layer, Doc: doc})
+		return nil
+	}
+
+	for _, path := range r.EnvFiles {
+		if err := add(path, envFileLayer(path)); err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range r.Variables {
+		for _, src := range v.Chain {
+			if src.Layer == resolver.LayerComposeEnvFile {
+				if err := add(src.File, src.Layer); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(ctx.Files, func(i, j int) bool {
+		return ctx.Files[i].Path < ctx.Files[j].Path
+	})
+	if err := ctx.readGitStatus(); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// readGitStatus sets the git fields of each file from the repository's
+// index and ignore files, the same way audit reads them
+func (ctx *Context) readGitStatus() error {
+	repo, err := gitrepo.Open(ctx.Path)
+	if errors.Is(err, gitrepo.ErrNotRepository) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	tracked, err := repo.TrackedFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range ctx.Files {
+		rel, err := repo.RelPath(f.Path)
+		if err != nil || strings.HasPrefix(rel, "../") {
+			continue // Outside the work tree
+		}
+		f.InRepo = true
+		f.Tracked = tracked[rel]
+		if f.Ignored, err = repo.IsIgnored(f.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func envFileLayer(path string) resolver.Layer {
+	switch filepath.Base(path) {
+	case ".env.example":
+		return resolver.LayerEnvExample
+	case ".env":
+		return resolver.LayerEnv
+	case ".env.local":
+		return resolver.LayerEnvLocal
+	default:
+		return resolver.LayerEnvOther
+	}
+}
+
+// Rule is a named check registered with the engine
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity // Default severity, overridable in config
+	Check       func(ctx *Context) []Diagnostic
+
+	// Fix repairs the given fixable diagnostics by editing ctx.Files in
+	// place. Nil for rules without a safe automatic fix
+	Fix func(ctx *Context, diags []Diagnostic)
+}
+
+var registry = map[string]*Rule{}
+
+// Register adds a rule; registering an ID twice panics
+func Register(r *Rule) {
+	if _, dup := registry[r.ID]; dup {
+		panic("lint: duplicate rule " + r.ID)
+	}
+	registry[r.ID] = r
+}
+
+// Rules returns all registered rules sorted by ID
+func Rules() []*Rule {
+	rules := make([]*Rule, 0, len(registry))
+	for _, r := range registry {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// Run executes every enabled rule, applies configured severities and
+// inline suppressions, and returns diagnostics sorted by location
+func Run(ctx *Context, cfg config.LintConfig) ([]Diagnostic, error) {
+	overrides := make(map[string]Severity)
+	disabled := make(map[string]bool)
+	for id, value := range cfg.Rules {
+		if _, ok := registry[id]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q in config", id)
+		}
+		sev, enabled, err := ParseSeverity(value)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", id, err)
+		}
+		if !enabled {
+			disabled[id] = true
+			continue
+		}
+		overrides[id] = sev
+	}
+
+	suppressed := suppressions(ctx.Files)
+
+	var diags []Diagnostic
+	for _, rule := range Rules() {
+		if disabled[rule.ID] {
+			continue
+		}
+		for _, d := range rule.Check(ctx) {
+			d.RuleID = rule.ID
+			d.Severity = rule.Severity
+			if sev, ok := overrides[rule.ID]; ok {
+				d.Severity = sev
+			}
+			if suppressed.matches(d) {
+				continue
+			}
+			diags = append(diags, d)
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Location, diags[j].Location
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return diags[i].RuleID < diags[j].RuleID
+	})
+	return diags, nil
+}
+
+// HasErrors reports whether any diagnostic is an error
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// DisableNextLine is the comment directive that suppresses diagnostics on
+// the following line. Rule IDs may follow, comma or space separated;
+// without IDs every rule is suppressed
+const DisableNextLine = "envmerge-disable-next-line"
+
+// isDirective reports whether l is a disable-next-line comment
+func isDirective(l *dotenv.Line) bool {
+	text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l.Raw), "#"))
+	return l.Kind == dotenv.LineComment && strings.HasPrefix(text, DisableNextLine)
+}
+
+// suppressionSet maps file -> line -> rule IDs ("*" for all)
+type suppressionSet map[string]map[int]map[string]bool
+
+func suppressions(files []*File) suppressionSet {
+	set := suppressionSet{}
+	for _, f := range files {
+		for i, l := range f.Doc.Lines {
+			if !isDirective(l) || i+1 >= len(f.Doc.Lines) {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l.Raw), "#"))
+
+			ids := strings.FieldsFunc(strings.TrimPrefix(text, DisableNextLine), func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t'
+			})
+			if len(ids) == 0 {
+				ids = []string{"*"}
+			}
+
+			target := f.Doc.Lines[i+1].Number
+			if set[f.Path] == nil {
+				set[f.Path] = map[int]map[string]bool{}
+			}
+			if set[f.Path][target] == nil {
+				set[f.Path][target] = map[string]bool{}
+			}
+			for _, id := range ids {
+				set[f.Path][target][id] = true
+			}
+		}
+	}
+	return set
+}
+
+func (s suppressionSet) matches(d Diagnostic) bool {
+	rules := s[d.Location.File][d.Location.Line]
+	return rules["*"] || rules[d.RuleID]
+}
@@ -0,0 +1,300 @@
+package lint
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/config"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+)
+
+func lintDir(t *testing.T, dir string, cfg config.LintConfig) []Diagnostic {
+	t.Helper()
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	s, err := schema.Discover(dir, "")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	ctx, err := NewContext(r, s)
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+	diags, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	return diags
+}
+
+func byRule(diags []Diagnostic) map[string][]Diagnostic {
+	m := make(map[string][]Diagnostic)
+	for _, d := range diags {
+		m[d.RuleID] = append(m[d.RuleID], d)
+	}
+	return m
+}
+
+func TestRun_BuiltinRules(t *testing.T) {
+	dir := t.TempDir()
+
+	envContent := "API_URL=http://a\n" +
+		"api_url=lower\n" +
+		"GREETING=hello world\n" +
+		"TRAILING=value   \n" +
+		"API_URL=http://b\n" +
+		"SHARED=same\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env.local"), []byte("SHARED=same\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env.example"), []byte("# @required\nTOKEN=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := byRule(lintDir(t, dir, config.LintConfig{}))
+
+	tests := []struct {
+		rule string
+		line int
+		sev  Severity
+	}{
+		{"duplicate-key", 5, SeverityError},
+		{"key-case", 2, SeverityWarning},
+		{"unquoted-spaces", 3, SeverityWarning},
+		{"trailing-whitespace", 4, SeverityWarning},
+		{"redundant-override", 1, SeverityInfo},
+		{"empty-required", 2, SeverityError},
+	}
+
+	for _, tc := range tests {
+		diags := got[tc.rule]
+		if len(diags) != 1 {
+			t.Errorf("%s: got %d diagnostics, want 1", tc.rule, len(diags))
+			continue
+		}
+		if diags[0].Location.Line != tc.line || diags[0].Severity != tc.sev {
+			t.Errorf("%s: line %d %s, want line %d %s",
+				tc.rule, diags[0].Location.Line, diags[0].Severity, tc.line, tc.sev)
+		}
+	}
+
+	if d := got["trailing-whitespace"]; len(d) == 1 && d[0].Location.Column != 15 {
+		t.Errorf("trailing-whitespace column = %d, want 15", d[0].Location.Column)
+	}
+}
+
+func TestRun_ConfigAndSuppression(t *testing.T) {
+	dir := t.TempDir()
+
+	envContent := "# envmerge-disable-next-line unquoted-spaces\n" +
+		"GREETING=hello world\n" +
+		"OTHER=two words\n" +
+		"lower=x\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.LintConfig{Rules: map[string]string{
+		"key-case":        "off",
+		"unquoted-spaces": "error",
+	}}
+	got := byRule(lintDir(t, dir, cfg))
+
+	if len(got["key-case"]) != 0 {
+		t.Error("key-case should be disabled by config")
+	}
+	spaces := got["unquoted-spaces"]
+	if len(spaces) != 1 || spaces[0].Location.Line != 3 {
+		t.Fatalf("unquoted-spaces = %+v, want only line 3", spaces)
+	}
+	if spaces[0].Severity != SeverityError {
+		t.Errorf("unquoted-spaces severity = %s, want error", spaces[0].Severity)
+	}
+}
+
+func TestRun_UnknownRuleInConfig(t *testing.T) {
+	ctx := &Context{Resolution: &resolver.Resolution{ByName: map[string]*resolver.Variable{}}}
+
+	if _, err := Run(ctx, config.LintConfig{Rules: map[string]string{"no-such-rule": "off"}}); err == nil {
+		t.Error("expected error for unknown rule")
+	}
+}
+
+func TestFix_PreservesCommentsAndFormatting(t *testing.T) {
+	dir := t.TempDir()
+
+	envContent := "# Service URLs\r\n" +
+		"export GREETING=hello world\r\n" +
+		"# envmerge-disable-next-line trailing-whitespace\r\n" +
+		"KEEP=spaced  \r\n" +
+		"TRIM=x  \r\n" +
+		"DUP=1\r\n" +
+		"DUP=1\r\n" +
+		"CHANGED=a\r\n" +
+		"CHANGED=b\r\n"
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := NewContext(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags, err := Run(ctx, config.LintConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := Fix(ctx, diags)
+	if len(changes) != 1 {
+		t.Fatalf("got %d changed files, want 1", len(changes))
+	}
+
+	want := "# Service URLs\r\n" +
+		"export GREETING='hello world'\r\n" +
+		"# envmerge-disable-next-line trailing-whitespace\r\n" +
+		"KEEP=spaced  \r\n" +
+		"TRIM=x\r\n" +
+		"DUP=1\r\n" +
+		"CHANGED=a\r\n" +
+		"CHANGED=b\r\n"
+	if changes[0].After != want {
+		t.Errorf("After = %q, want %q", changes[0].After, want)
+	}
+
+	// Nothing is written until Write is called
+	data, _ := os.ReadFile(path)
+	if string(data) != envContent {
+		t.Error("Fix should not modify files on disk")
+	}
+}
+
+func TestFix_SortsKeysByDefault(t *testing.T) {
+	dir := t.TempDir()
+	envContent := "DB_USER=app\nDB_HOST=db\n\nZONE=eu\nREGION=west\n" +
+		"# envmerge-disable-next-line trailing-whitespace\nPADDED=x  \nALPHA=1\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := NewContext(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags, err := Run(ctx, config.LintConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := Fix(ctx, diags)
+	if len(changes) != 1 {
+		t.Fatalf("got %d changed files, want 1", len(changes))
+	}
+	want := "DB_HOST=db\nDB_USER=app\n\nREGION=west\nZONE=eu\n" +
+		"# envmerge-disable-next-line trailing-whitespace\nPADDED=x  \nALPHA=1\n"
+	if changes[0].After != want {
+		t.Errorf("After = %q, want %q", changes[0].After, want)
+	}
+}
+
+func TestFix_KeepsAnnotatedKeysInPlace(t *testing.T) {
+	dir := t.TempDir()
+	envContent := "# @type=url @required Database connection\nDB_URL=postgres://db\nAPI_KEY=abc\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := NewContext(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags, err := Run(ctx, config.LintConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diags {
+		if d.RuleID == "unsorted-keys" {
+			t.Errorf("unexpected diagnostic %s", d.Message)
+		}
+	}
+
+	if changes := Fix(ctx, diags); len(changes) != 0 {
+		t.Errorf("After = %q, want file unchanged", changes[0].After)
+	}
+}
+
+func TestRun_LocalCommitted(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(dir string)
+		message string
+	}{
+		{"tracked", func(dir string) { git(dir, "add", ".env.local") }, ".env.local is committed to git"},
+		{"not ignored", func(dir string) {}, ".env.local is not covered by .gitignore"},
+		{"ignored", func(dir string) {
+			os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".env.local\n"), 0644)
+		}, ""},
+	}
+	for _, tc := range tests {
+		dir := t.TempDir()
+		git(dir, "init", "-q")
+		os.WriteFile(filepath.Join(dir, ".env.local"), []byte("A=1\n"), 0644)
+		tc.setup(dir)
+
+		got := byRule(lintDir(t, dir, config.LintConfig{}))["local-committed"]
+		switch {
+		case tc.message == "" && len(got) != 0:
+			t.Errorf("%s: got %+v, want no diagnostic", tc.name, got)
+		case tc.message != "" && (len(got) != 1 || !strings.HasPrefix(got[0].Message, tc.message)):
+			t.Errorf("%s: got %+v, want %q", tc.name, got, tc.message)
+		}
+	}
+}
+
+func TestNewContext_LintsFilesWithDotenvxValues(t *testing.T) {
+	dir := t.TempDir()
+	envContent := "SECRET=\"encrypted:BDb7t0B6Nld3Lc2RpJv1M+dTWXcEAPqjwXC9a8kTnOaXtYXY=\"\nlower_case=1\n"
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := byRule(lintDir(t, dir, config.LintConfig{}))
+	if len(got["key-case"]) != 1 {
+		t.Errorf("key-case = %+v, want lower_case reported", got["key-case"])
+	}
+}
@@ -0,0 +1,366 @@
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+	"github.com/stackgen-cli/envmerge/internal/example"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+func init() {
+	Register(&Rule{
+		ID:          "duplicate-key",
+		Description: "A key is defined more than once in the same file",
+		Severity:    SeverityError,
+		Check:       checkDuplicateKey,
+		Fix:         fixDuplicateKey,
+	})
+	Register(&Rule{
+		ID:          "key-case",
+		Description: "Keys should be UPPER_SNAKE_CASE",
+		Severity:    SeverityWarning,
+		Check:       checkKeyCase,
+	})
+	Register(&Rule{
+		ID:          "trailing-whitespace",
+		Description: "Unquoted value has trailing whitespace that will be dropped",
+		Severity:    SeverityWarning,
+		Check:       checkTrailingWhitespace,
+		Fix:         fixRequote,
+	})
+	Register(&Rule{
+		ID:          "unquoted-spaces",
+		Description: "Value containing spaces should be quoted",
+		Severity:    SeverityWarning,
+		Check:       checkUnquotedSpaces,
+		Fix:         fixRequote,
+	})
+	Register(&Rule{
+		ID:          "local-committed",
+		Description: "A .env.local file is tracked by git or not covered by .gitignore",
+		Severity:    SeverityError,
+		Check:       checkLocalCommitted,
+	})
+	Register(&Rule{
+		ID:          "redundant-override",
+		Description: "A higher layer sets the same value as the layer it overrides",
+		Severity:    SeverityInfo,
+		Check:       checkRedundantOverride,
+	})
+	Register(&Rule{
+		ID:          "empty-required",
+		Description: "A variable the schema marks as required resolves to an empty value",
+		Severity:    SeverityError,
+		Check:       checkEmptyRequired,
+	})
+	Register(&Rule{
+		ID:          "example-missing-key",
+		Description: "A key used by the project is missing from .env.example",
+		Severity:    SeverityWarning,
+		Check:       checkExampleMissingKey,
+		Fix:         fixExampleMissingKey,
+	})
+	Register(&Rule{
+		ID:          "unsorted-keys",
+		Description: "Keys within a block (between blank lines or comments) are not sorted; blocks under a comment are skipped",
+		Severity:    SeverityInfo,
+		Check:       checkUnsortedKeys,
+		Fix:         fixUnsortedKeys,
+	})
+}
+
+// entries calls fn for every key=value line of every file
+func entries(ctx *Context, fn func(f *File, l *dotenv.Line)) {
+	for _, f := range ctx.Files {
+		for _, l := range f.Doc.Lines {
+			if l.Kind == dotenv.LineEntry {
+				fn(f, l)
+			}
+		}
+	}
+}
+
+func checkDuplicateKey(ctx *Context) []Diagnostic {
+	var diags []Diagnostic
+	for _, f := range ctx.Files {
+		previous := make(map[string]*dotenv.Line)
+		for _, l := range f.Doc.Lines {
+			if l.Kind != dotenv.LineEntry {
+				continue
+			}
+			prev, dup := previous[l.Key]
+			previous[l.Key] = l
+			if dup {
+				diags = append(diags, Diagnostic{
+					Variable: l.Key,
+					Message:  fmt.Sprintf("%s is already defined on line %d; the last definition wins", l.Key, prev.Number),
+					Location: Location{File: f.Path, Line: l.Number, Column: l.KeyColumn()},
+					// Only an exact repeat can be dropped without changing the result
+					Fixable: prev.Value == l.Value,
+				})
+			}
+		}
+	}
+	return diags
+}
+
+func fixDuplicateKey(ctx *Context, diags []Diagnostic) {
+	for _, d := range diags {
+		f := ctx.file(d.Location.File)
+		if f == nil {
+			continue
+		}
+		if i := f.Doc.LineAt(d.Location.Line); i >= 0 {
+			f.Doc.Lines = append(f.Doc.Lines[:i], f.Doc.Lines[i+1:]...)
+		}
+	}
+}
+
+var upperSnake = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+func checkKeyCase(ctx *Context) []Diagnostic {
+	var diags []Diagnostic
+	entries(ctx, func(f *File, l *dotenv.Line) {
+		if !upperSnake.MatchString(l.Key) {
+			diags = append(diags, Diagnostic{
+				Variable: l.Key,
+				Message:  fmt.Sprintf("%s is not UPPER_SNAKE_CASE", l.Key),
+				Location: Location{File: f.Path, Line: l.Number, Column: l.KeyColumn()},
+			})
+		}
+	})
+	return diags
+}
+
+func checkTrailingWhitespace(ctx *Context) []Diagnostic {
+	var diags []Diagnostic
+	entries(ctx, func(f *File, l *dotenv.Line) {
+		trimmed := strings.TrimRight(l.RawValue, " \t")
+		if l.Quote != 0 || trimmed == l.RawValue || strings.TrimSpace(trimmed) == "" {
+			return
+		}
+		diags = append(diags, Diagnostic{
+			Variable: l.Key,
+			Message:  fmt.Sprintf("%s has trailing whitespace after its value", l.Key),
+			Location: Location{File: f.Path, Line: l.Number, Column: l.ValueColumn() + len(trimmed)},
+			Fixable:  true,
+		})
+	})
+	return diags
+}
+
+func checkUnquotedSpaces(ctx *Context) []Diagnostic {
+	var diags []Diagnostic
+	entries(ctx, func(f *File, l *dotenv.Line) {
+		if l.Quote != 0 || !strings.ContainsAny(l.Value, " \t") {
+			return
+		}
+		diags = append(diags, Diagnostic{
+			Variable: l.Key,
+			Message:  fmt.Sprintf("%s contains spaces but is not quoted", l.Key),
+			Location: Location{File: f.Path, Line: l.Number, Column: l.ValueColumn()},
+			Fixable:  true,
+		})
+	})
+	return diags
+}
+
+// fixRequote rewrites the flagged entries from their parsed value, which
+// drops stray whitespace and adds quotes where they are needed
+func fixRequote(ctx *Context, diags []Diagnostic) {
+	for _, d := range diags {
+		f := ctx.file(d.Location.File)
+		if f == nil {
+			continue
+		}
+		if i := f.Doc.LineAt(d.Location.Line); i >= 0 {
+			l := f.Doc.Lines[i]
+			l.SetValue(l.Value)
+		}
+	}
+}
+
+func checkLocalCommitted(ctx *Context) []Diagnostic {
+	var diags []Diagnostic
+	for _, f := range ctx.Files {
+		if f.Layer != resolver.LayerEnvLocal || !f.InRepo {
+			continue
+		}
+		var problem string
+		switch {
+		case f.Tracked:
+			problem = "is committed to git"
+		case !f.Ignored:
+			problem = "is not covered by .gitignore"
+		default:
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Message:  fmt.Sprintf("%s %s; local overrides should be ignored", filepath.Base(f.Path), problem),
+			Location: Location{File: f.Path},
+		})
+	}
+	return diags
+}
+
+func checkRedundantOverride(ctx *Context) []Diagnostic {
+	var diags []Diagnostic
+	for _, v := range ctx.Resolution.Variables {
+		for i := 1; i < len(v.Chain); i++ {
+			lower, higher := v.Chain[i-1], v.Chain[i]
+			if lower.Layer == resolver.LayerEnvExample || higher.File == "" {
+				continue
+			}
+			if lower.Value == "" || lower.Value != higher.Value || lower.Layer == higher.Layer {
+				continue
+			}
+			if higher.Service != "" && lower.Service != "" && higher.Service != lower.Service {
+				continue // Different services, not an override
+			}
+			diags = append(diags, Diagnostic{
+				Variable: v.Name,
+				Message: fmt.Sprintf("%s overrides %s with the same value",
+					v.Name, lower.Location()),
+				Location: Location{File: higher.File, Line: higher.Line},
+			})
+		}
+	}
+	return diags
+}
+
+func checkEmptyRequired(ctx *Context) []Diagnostic {
+	if ctx.Schema == nil {
+		return nil
+	}
+	var diags []Diagnostic
+	for _, name := range ctx.Schema.Order {
+		f := ctx.Schema.Fields[name]
+		v := ctx.Resolution.ByName[name]
+		if !f.Required || f.Default != nil || v == nil || len(v.Chain) == 0 || v.FinalValue != "" || v.FinalFrom.Unresolvable {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Variable: name,
+			Message:  fmt.Sprintf("%s is required but resolves to an empty value", name),
+			Location: Location{File: v.FinalFrom.File, Line: v.FinalFrom.Line},
+		})
+	}
+	return diags
+}
+
+func checkExampleMissingKey(ctx *Context) []Diagnostic {
+	f := exampleFile(ctx)
+	if f == nil {
+		return nil
+	}
+	var diags []Diagnostic
+	for _, name := range example.Diff(ctx.Resolution, f.Doc).Missing {
+		diags = append(diags, Diagnostic{
+			Variable: name,
+			Message:  fmt.Sprintf("%s is used but missing from %s", name, example.FileName),
+			Location: Location{File: f.Path},
+			Fixable:  true,
+		})
+	}
+	return diags
+}
+
+func fixExampleMissingKey(ctx *Context, diags []Diagnostic) {
+	f := exampleFile(ctx)
+	if f == nil {
+		return
+	}
+	drift := &example.Drift{}
+	for _, d := range diags {
+		drift.Missing = append(drift.Missing, d.Variable)
+	}
+	example.Apply(f.Doc, ctx.Resolution, drift, example.Options{})
+}
+
+func exampleFile(ctx *Context) *File {
+	for _, f := range ctx.Files {
+		if f.Layer == resolver.LayerEnvExample {
+			return f
+		}
+	}
+	return nil
+}
+
+// blocks returns runs of consecutive entry lines as [start, end) indexes
+func blocks(doc *dotenv.Document) [][2]int {
+	var out [][2]int
+	start := -1
+	for i, l := range doc.Lines {
+		if l.Kind == dotenv.LineEntry {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			out = append(out, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		out = append(out, [2]int{start, len(doc.Lines)})
+	}
+	return out
+}
+
+func checkUnsortedKeys(ctx *Context) []Diagnostic {
+	var diags []Diagnostic
+	for _, f := range ctx.Files {
+		for _, b := range blocks(f.Doc) {
+			// A comment directly above the first key describes, annotates
+			// or suppresses that key; sorting would hand it to another, so
+			// such blocks are left as they are
+			if b[0] > 0 && f.Doc.Lines[b[0]-1].Kind == dotenv.LineComment {
+				continue
+			}
+			lines := f.Doc.Lines[b[0]:b[1]]
+			for i := 1; i < len(lines); i++ {
+				if lines[i].Key < lines[i-1].Key {
+					diags = append(diags, Diagnostic{
+						Variable: lines[i].Key,
+						Message:  fmt.Sprintf("%s should come before %s", lines[i].Key, lines[i-1].Key),
+						Location: Location{File: f.Path, Line: lines[i].Number, Column: lines[i].KeyColumn()},
+						Fixable:  true,
+					})
+					break
+				}
+			}
+		}
+	}
+	return diags
+}
+
+func fixUnsortedKeys(ctx *Context, diags []Diagnostic) {
+	for _, d := range diags {
+		f := ctx.file(d.Location.File)
+		if f == nil {
+			continue
+		}
+		for _, b := range blocks(f.Doc) {
+			lines := f.Doc.Lines[b[0]:b[1]]
+			if containsLine(lines, d.Location.Line) {
+				// Stable, so repeated keys keep their relative order
+				sort.SliceStable(lines, func(i, j int) bool { return lines[i].Key < lines[j].Key })
+			}
+		}
+	}
+}
+
+func containsLine(lines []*dotenv.Line, number int) bool {
+	for _, l := range lines {
+		if l.Number == number {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/envmerge/internal/audit"
+)
+
+// FormatAuditText renders an audit report for the terminal
+func FormatAuditText(r *audit.Report) string {
+	var sb strings.Builder
+
+	bold := color.New(color.Bold).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+	dim := color.New(color.FgHiBlack).SprintFunc()
+
+	if len(r.Files) > 0 {
+		sb.WriteString(bold("🔓 Env Files With Secrets\n"))
+		for _, f := range r.Files {
+			sb.WriteString(fmt.Sprintf("  %s %s\n", f.Path, red("("+f.Problem()+")")))
+			for _, s := range f.Secrets {
+				sb.WriteString(fmt.Sprintf("    line %d: %s = %s %s\n", s.Line, s.Key, s.Preview, dim(s.Reason)))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(r.History) > 0 {
+		sb.WriteString(bold("🕰️  Secrets In Git History\n"))
+		for _, h := range r.History {
+			sb.WriteString(fmt.Sprintf("  %s %s %s: %s = %s %s\n",
+				h.Commit[:8], h.Date.Format("2006-01-02"), h.File, h.Key, h.Preview, dim(h.Reason)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if r.HistoryScanned {
+		sb.WriteString(dim(fmt.Sprintf("Scanned %d commit(s)\n", r.CommitsScanned)))
+	}
+	if !r.HasFindings() {
+		sb.WriteString(color.GreenString("✅ No committed secrets found\n"))
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("%d file(s) at risk, %d secret(s) in history\n", len(r.Files), len(r.History)))
+	return sb.String()
+}
+
+// FormatAuditJSON renders an audit report as JSON
+func FormatAuditJSON(r *audit.Report) (string, error) {
+	type jsonSecret struct {
+		Key     string `json:"key"`
+		Line    int    `json:"line"`
+		Reason  string `json:"reason"`
+		Preview string `json:"preview"`
+	}
+	type jsonFile struct {
+		Path    string       `json:"path"`
+		Tracked bool         `json:"tracked"`
+		Ignored bool         `json:"ignored"`
+		Problem string       `json:"problem"`
+		Secrets []jsonSecret `json:"secrets"`
+	}
+	type jsonHistory struct {
+		Commit  string `json:"commit"`
+		Date    string `json:"date"`
+		Subject string `json:"subject"`
+		File    string `json:"file"`
+		Key     string `json:"key"`
+		Reason  string `json:"reason"`
+		Preview string `json:"preview"`
+	}
+	type jsonReport struct {
+		Path           string        `json:"path"`
+		Repository     string        `json:"repository"`
+		Files          []jsonFile    `json:"files"`
+		History        []jsonHistory `json:"history,omitempty"`
+		CommitsScanned int           `json:"commits_scanned,omitempty"`
+	}
+
+	out := jsonReport{
+		Path:           r.Path,
+		Repository:     r.Repository,
+		Files:          []jsonFile{},
+		CommitsScanned: r.CommitsScanned,
+	}
+	for _, f := range r.Files {
+		jf := jsonFile{Path: f.Path, Tracked: f.Tracked, Ignored: f.Ignored, Problem: f.Problem()}
+		for _, s := range f.Secrets {
+			jf.Secrets = append(jf.Secrets, jsonSecret{Key: s.Key, Line: s.Line, Reason: s.Reason, Preview: s.Preview})
+		}
+		out.Files = append(out.Files, jf)
+	}
+	for _, h := range r.History {
+		out.History = append(out.History, jsonHistory{
+			Commit:  h.Commit,
+			Date:    h.Date.Format("2006-01-02T15:04:05Z07:00"),
+			Subject: h.Subject,
+			File:    h.File,
+			Key:     h.Key,
+			Reason:  h.Reason,
+			Preview: h.Preview,
+		})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
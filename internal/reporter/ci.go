@@ -0,0 +1,148 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/lint"
+)
+
+// JUnit XML, as read by most CI dashboards
+type (
+	junitSuites struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// FormatJUnitByRule renders one test case per rule. A rule fails when it
+// has error or warning diagnostics; info diagnostics are kept as output
+func FormatJUnitByRule(suite string, rules []RuleInfo, diags []lint.Diagnostic) (string, error) {
+	var names []string
+	for _, r := range rules {
+		names = append(names, r.ID)
+	}
+	return formatJUnit(suite, names, diags, func(d lint.Diagnostic) string { return d.RuleID })
+}
+
+// FormatJUnitByVariable renders one test case per variable, plus one per
+// rule for diagnostics that concern no single variable
+func FormatJUnitByVariable(suite string, variables []string, diags []lint.Diagnostic) (string, error) {
+	names := append([]string(nil), variables...)
+	known := make(map[string]bool)
+	for _, n := range names {
+		known[n] = true
+	}
+	key := func(d lint.Diagnostic) string {
+		if d.Variable != "" {
+			return d.Variable
+		}
+		return d.RuleID
+	}
+	for _, d := range diags {
+		if k := key(d); !known[k] {
+			known[k] = true
+			names = append(names, k)
+		}
+	}
+	return formatJUnit(suite, names, diags, key)
+}
+
+func formatJUnit(suite string, names []string, diags []lint.Diagnostic, key func(lint.Diagnostic) string) (string, error) {
+	grouped := make(map[string][]lint.Diagnostic)
+	for _, d := range diags {
+		grouped[key(d)] = append(grouped[key(d)], d)
+	}
+
+	s := junitSuite{Name: suite, Cases: []junitCase{}}
+	for _, name := range names {
+		c := junitCase{Name: name, Classname: suite}
+		var failed, passed []string
+		for _, d := range grouped[name] {
+			line := fmt.Sprintf("%s: %s: %s [%s]", d.Location, d.Severity, d.Message, d.RuleID)
+			if d.Severity >= lint.SeverityWarning {
+				failed = append(failed, line)
+			} else {
+				passed = append(passed, line)
+			}
+		}
+		if len(failed) > 0 {
+			c.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d problem(s)", len(failed)),
+				Type:    "envmerge",
+				Text:    strings.Join(failed, "\n"),
+			}
+			s.Failures++
+		}
+		c.SystemOut = strings.Join(passed, "\n")
+		s.Cases = append(s.Cases, c)
+	}
+	s.Tests = len(s.Cases)
+
+	out := junitSuites{Tests: s.Tests, Failures: s.Failures, Suites: []junitSuite{s}}
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data), nil
+}
+
+// FormatGitHub renders diagnostics as GitHub Actions workflow commands, so
+// they show up as annotations on the changed files
+func FormatGitHub(diags []lint.Diagnostic) string {
+	var sb strings.Builder
+	for _, d := range diags {
+		command := "notice"
+		switch d.Severity {
+		case lint.SeverityError:
+			command = "error"
+		case lint.SeverityWarning:
+			command = "warning"
+		}
+
+		props := []string{}
+		if d.Location.File != "" {
+			props = append(props, "file="+escapeProperty(d.Location.File))
+		}
+		if d.Location.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", d.Location.Line))
+		}
+		if d.Location.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", d.Location.Column))
+		}
+		props = append(props, "title="+escapeProperty("envmerge "+d.RuleID))
+
+		sb.WriteString(fmt.Sprintf("::%s %s::%s\n", command, strings.Join(props, ","), escapeData(d.Message)))
+	}
+	return sb.String()
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,85 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/lint"
+)
+
+var ciDiags = []lint.Diagnostic{
+	{RuleID: "duplicate-key", Severity: lint.SeverityWarning, Message: "PORT is defined twice", Location: lint.Location{File: ".env", Line: 3, Column: 1}, Variable: "PORT"},
+	{RuleID: "override", Severity: lint.SeverityInfo, Message: "PORT is overridden", Location: lint.Location{File: ".env.local", Line: 1}, Variable: "PORT"},
+	{RuleID: "resolution-warning", Severity: lint.SeverityWarning, Message: "bad: file,\n50% read", Location: lint.Location{File: "dir:a,b"}},
+}
+
+func TestFormatJUnitByVariable_FailsOnViolations(t *testing.T) {
+	out, err := FormatJUnitByVariable("envmerge scan", []string{"HOST", "PORT"}, ciDiags)
+	if err != nil {
+		t.Fatalf("FormatJUnitByVariable failed: %v", err)
+	}
+
+	var suites junitSuites
+	if err := xml.Unmarshal([]byte(out), &suites); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if suites.Tests != 3 || suites.Failures != 2 {
+		t.Errorf("tests = %d, failures = %d, want 3 and 2", suites.Tests, suites.Failures)
+	}
+
+	cases := suites.Suites[0].Cases
+	if cases[0].Name != "HOST" || cases[0].Failure != nil {
+		t.Errorf("case 1 = %+v, want passing HOST", cases[0])
+	}
+	if cases[1].Failure == nil || !strings.Contains(cases[1].Failure.Text, "defined twice") {
+		t.Errorf("case 2 = %+v, want PORT failing on the duplicate", cases[1])
+	}
+	if strings.Contains(cases[1].Failure.Text, "overridden") || !strings.Contains(cases[1].SystemOut, "overridden") {
+		t.Errorf("info diagnostics should be output, not failures: %+v", cases[1])
+	}
+	if cases[2].Name != "resolution-warning" {
+		t.Errorf("case 3 = %s, want resolution-warning", cases[2].Name)
+	}
+}
+
+func TestFormatJUnitByRule_OneCasePerRule(t *testing.T) {
+	rules := []RuleInfo{{ID: "duplicate-key"}, {ID: "trailing-whitespace"}}
+	out, err := FormatJUnitByRule("envmerge lint", rules, ciDiags[:1])
+	if err != nil {
+		t.Fatalf("FormatJUnitByRule failed: %v", err)
+	}
+	if !strings.Contains(out, `<testcase name="trailing-whitespace" classname="envmerge lint"></testcase>`) {
+		t.Errorf("output should have a passing case for trailing-whitespace:\n%s", out)
+	}
+	if !strings.Contains(out, `failures="1"`) {
+		t.Errorf("output should count one failure:\n%s", out)
+	}
+}
+
+func TestFormatGitHub_Escaping(t *testing.T) {
+	got := strings.Split(strings.TrimSuffix(FormatGitHub(ciDiags), "\n"), "\n")
+	want := []string{
+		"::warning file=.env,line=3,col=1,title=envmerge duplicate-key::PORT is defined twice",
+		"::notice file=.env.local,line=1,title=envmerge override::PORT is overridden",
+		"::warning file=dir%3Aa%2Cb,title=envmerge resolution-warning::bad: file,%0A50%25 read",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %s, want %s", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestFormatGitHub_HidesDetectedSecrets(t *testing.T) {
+	out := FormatGitHub(detectedSecretDiagnostics(t))
+	if !strings.Contains(out, "title=envmerge "+RuleSchema) {
+		t.Errorf("missing %s annotation:\n%s", RuleSchema, out)
+	}
+	if strings.Contains(out, detectedSecret) {
+		t.Errorf("GitHub output contains the secret value:\n%s", out)
+	}
+}
@@ -0,0 +1,212 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/lint"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// Compare finding rule IDs
+const (
+	RuleCompareMissing   = "compare-missing"
+	RuleCompareDifferent = "compare-different"
+)
+
+// CompareRules returns metadata for the findings CompareDiagnostics reports
+func CompareRules() []RuleInfo {
+	return []RuleInfo{
+		{RuleCompareDifferent, "A variable has different values in the compared environments", lint.SeverityWarning},
+		{RuleCompareMissing, "A variable is defined in only one of the compared environments", lint.SeverityWarning},
+	}
+}
+
+// CompareDiagnostics turns a comparison into diagnostics, located at the
+// side that defines the variable (the first side for differences).
+// Messages never include values, which may be secret
+func CompareDiagnostics(c *Comparison) []lint.Diagnostic {
+	var diags []lint.Diagnostic
+	add := func(rule, variable, message string, src resolver.Source, path string) {
+		diags = append(diags, lint.Diagnostic{
+			RuleID:   rule,
+			Severity: lint.SeverityWarning,
+			Message:  message,
+			Location: sourceDiagnosticLocation(src, path),
+			Variable: variable,
+		})
+	}
+
+	res := c.Result
+	for _, name := range res.OnlyInFirst {
+		add(RuleCompareMissing, name, fmt.Sprintf("%s is defined in %s but not in %s", name, c.First, c.Second),
+			res.FirstFrom[name], c.First)
+	}
+	for _, name := range res.OnlyInSecond {
+		add(RuleCompareMissing, name, fmt.Sprintf("%s is defined in %s but not in %s", name, c.Second, c.First),
+			res.SecondFrom[name], c.Second)
+	}
+	for _, d := range res.Different {
+		add(RuleCompareDifferent, d.Name, fmt.Sprintf("%s differs between %s (%s) and %s (%s)",
+			d.Name, c.First, d.FirstFrom.Location(), c.Second, d.SecondFrom.Location()),
+			d.FirstFrom, c.First)
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		return diags[i].Variable < diags[j].Variable
+	})
+	return diags
+}
+
+// JSONCompare is the document written by scan --compare --format json
+type JSONCompare struct {
+	SchemaVersion string             `json:"schema_version"`
+	First         string             `json:"first"`
+	Second        string             `json:"second"`
+	OnlyInFirst   []JSONCompareEntry `json:"only_in_first"`
+	OnlyInSecond  []JSONCompareEntry `json:"only_in_second"`
+	Different     []JSONCompareDiff  `json:"different"`
+	Same          []string           `json:"same"`
+	Ignored       []string           `json:"ignored"` // Left out by an ignore pattern
+}
+
+// JSONCompareEntry is a variable defined on one side only
+type JSONCompareEntry struct {
+	Name   string     `json:"name"`
+	Source JSONSource `json:"source"`
+}
+
+// JSONCompareDiff is a variable with a different value on each side
+type JSONCompareDiff struct {
+	Name   string     `json:"name"`
+	First  JSONSource `json:"first"`
+	Second JSONSource `json:"second"`
+}
+
+// FormatCompareJSON generates JSON output for a comparison
+func FormatCompareJSON(c *Comparison) (string, error) {
+	res := c.Result
+	out := JSONCompare{
+		SchemaVersion: SchemaVersion,
+		First:         c.First,
+		Second:        c.Second,
+		OnlyInFirst:   []JSONCompareEntry{},
+		OnlyInSecond:  []JSONCompareEntry{},
+		Different:     []JSONCompareDiff{},
+		Same:          nonNil(res.Same),
+		Ignored:       nonNil(res.Ignored),
+	}
+	for _, name := range res.OnlyInFirst {
+		out.OnlyInFirst = append(out.OnlyInFirst, JSONCompareEntry{Name: name, Source: newJSONSource(res.FirstFrom[name])})
+	}
+	for _, name := range res.OnlyInSecond {
+		out.OnlyInSecond = append(out.OnlyInSecond, JSONCompareEntry{Name: name, Source: newJSONSource(res.SecondFrom[name])})
+	}
+	for _, d := range res.Different {
+		out.Different = append(out.Different, JSONCompareDiff{
+			Name:   d.Name,
+			First:  newJSONSource(d.FirstFrom),
+			Second: newJSONSource(d.SecondFrom),
+		})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// FormatCompareMarkdown generates Markdown output for a comparison
+func FormatCompareMarkdown(c *Comparison) string {
+	var sb strings.Builder
+	res := c.Result
+
+	sb.WriteString(fmt.Sprintf("# Environment Comparison: `%s` vs `%s`\n\n", c.First, c.Second))
+	sb.WriteString("| | Count |\n")
+	sb.WriteString("|---|---|\n")
+	sb.WriteString(fmt.Sprintf("| Only in `%s` | %d |\n", c.First, len(res.OnlyInFirst)))
+	sb.WriteString(fmt.Sprintf("| Only in `%s` | %d |\n", c.Second, len(res.OnlyInSecond)))
+	sb.WriteString(fmt.Sprintf("| Different | %d |\n", len(res.Different)))
+	sb.WriteString(fmt.Sprintf("| Same | %d |\n", len(res.Same)))
+	if len(res.Ignored) > 0 {
+		sb.WriteString(fmt.Sprintf("| Ignored | %d |\n", len(res.Ignored)))
+	}
+
+	if len(res.Different) > 0 {
+		sb.WriteString("\n## Different Values\n\n")
+		sb.WriteString(fmt.Sprintf("| Variable | `%s` | Source | `%s` | Source |\n", c.First, c.Second))
+		sb.WriteString("|----------|------|--------|------|--------|\n")
+		for _, d := range res.Different {
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n", d.Name,
+				markdownValue(d.FirstValue), d.FirstFrom.Location(), markdownValue(d.SecondValue), d.SecondFrom.Location()))
+		}
+	}
+
+	writeOnly := func(side string, names []string, from map[string]resolver.Source) {
+		if len(names) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n## Only in `%s`\n\n", side))
+		for _, name := range names {
+			sb.WriteString(fmt.Sprintf("- `%s` (%s)\n", name, from[name].Location()))
+		}
+	}
+	writeOnly(c.First, res.OnlyInFirst, res.FirstFrom)
+	writeOnly(c.Second, res.OnlyInSecond, res.SecondFrom)
+
+	return sb.String()
+}
+
+// markdownValue quotes a value for a table cell
+func markdownValue(s string) string {
+	return "`" + strings.ReplaceAll(s, "|", "\\|") + "`"
+}
+
+// compareFormatters are the formats scan --compare supports. They get the
+// first resolution and opts.Compare, which is never nil
+var compareFormatters = map[string]Formatter{
+	"text": func(w io.Writer, _ *resolver.Resolution, opts Options) error {
+		c := opts.Compare
+		return writeLine(w, resolver.FormatCompare(c.First, c.Second, c.Result), nil)
+	},
+	"json": func(w io.Writer, _ *resolver.Resolution, opts Options) error {
+		out, err := FormatCompareJSON(opts.Compare)
+		return writeLine(w, out, err)
+	},
+	"markdown": func(w io.Writer, _ *resolver.Resolution, opts Options) error {
+		return writeLine(w, FormatCompareMarkdown(opts.Compare), nil)
+	},
+	"html": formatters["html"],
+	"sarif": func(w io.Writer, _ *resolver.Resolution, opts Options) error {
+		out, err := FormatSARIF(CompareRules(), CompareDiagnostics(opts.Compare), opts.Version)
+		return writeLine(w, out, err)
+	},
+	"junit": func(w io.Writer, _ *resolver.Resolution, opts Options) error {
+		out, err := FormatJUnitByRule("envmerge compare", CompareRules(), CompareDiagnostics(opts.Compare))
+		return writeLine(w, out, err)
+	},
+	"github": func(w io.Writer, _ *resolver.Resolution, opts Options) error {
+		_, err := io.WriteString(w, FormatGitHub(CompareDiagnostics(opts.Compare)))
+		return err
+	},
+}
+
+// LookupCompare returns the compare formatter registered as name
+func LookupCompare(name string) (Formatter, bool) {
+	f, ok := compareFormatters[name]
+	return f, ok
+}
+
+// CompareFormats lists the formats that support a comparison, sorted
+func CompareFormats() []string {
+	names := make([]string, 0, len(compareFormatters))
+	for name := range compareFormatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,96 @@
+package reporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+func testComparison(t *testing.T) *Comparison {
+	t.Helper()
+	first, second := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(first, ".env"), []byte("PORT=3000\nDEBUG=1\nAPI_KEY=dev-key\n"), 0644)
+	os.WriteFile(filepath.Join(second, ".env"), []byte("PORT=4000\nAPI_KEY=dev-key\nREGION=eu\n"), 0644)
+
+	a, err := resolver.Resolve(first)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	b, err := resolver.Resolve(second)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	return &Comparison{First: first, Second: second, Result: resolver.Compare(a, b)}
+}
+
+func TestCompareDiagnostics_LocatedPerSide(t *testing.T) {
+	c := testComparison(t)
+	diags := CompareDiagnostics(c)
+	if len(diags) != 3 {
+		t.Fatalf("got %d diagnostics, want 3: %+v", len(diags), diags)
+	}
+
+	byVar := make(map[string]int)
+	for i, d := range diags {
+		byVar[d.Variable] = i
+	}
+	if d := diags[byVar["REGION"]]; d.RuleID != RuleCompareMissing || d.Location.File != filepath.Join(c.Second, ".env") || d.Location.Line != 3 {
+		t.Errorf("REGION = %+v, want compare-missing at the second .env:3", d)
+	}
+	if d := diags[byVar["PORT"]]; d.RuleID != RuleCompareDifferent || d.Location.Line != 1 {
+		t.Errorf("PORT = %+v, want compare-different at line 1", d)
+	}
+	for _, d := range diags {
+		if strings.Contains(d.Message, "3000") || strings.Contains(d.Message, "4000") {
+			t.Errorf("message should not include values: %s", d.Message)
+		}
+	}
+}
+
+func TestFormatCompareJSON_Sources(t *testing.T) {
+	out, err := FormatCompareJSON(testComparison(t))
+	if err != nil {
+		t.Fatalf("FormatCompareJSON failed: %v", err)
+	}
+
+	var doc JSONCompare
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.SchemaVersion != SchemaVersion {
+		t.Errorf("schema_version = %s, want %s", doc.SchemaVersion, SchemaVersion)
+	}
+	if len(doc.Different) != 1 || doc.Different[0].First.Value != "3000" || doc.Different[0].Second.Line != 1 {
+		t.Errorf("different = %+v, want PORT with both sources", doc.Different)
+	}
+	if len(doc.OnlyInFirst) != 1 || doc.OnlyInFirst[0].Source.Line != 2 {
+		t.Errorf("only_in_first = %+v, want DEBUG at line 2", doc.OnlyInFirst)
+	}
+	if strings.Join(doc.Same, ",") != "API_KEY" || doc.Ignored == nil {
+		t.Errorf("same = %v, ignored = %v, want [API_KEY] and []", doc.Same, doc.Ignored)
+	}
+}
+
+func TestLookupCompare_Formats(t *testing.T) {
+	for _, name := range []string{"text", "json", "markdown", "sarif", "html"} {
+		if _, ok := LookupCompare(name); !ok {
+			t.Errorf("LookupCompare(%s) not found", name)
+		}
+	}
+	if _, ok := LookupCompare("csv"); ok {
+		t.Error("csv should not support compare")
+	}
+
+	format, _ := LookupCompare("markdown")
+	var sb strings.Builder
+	if err := format(&sb, nil, Options{Compare: testComparison(t)}); err != nil {
+		t.Fatalf("markdown failed: %v", err)
+	}
+	if !strings.Contains(sb.String(), "| `PORT` | `3000` |") {
+		t.Errorf("markdown missing PORT row:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,56 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// csvHeader names the columns written by writeCSV
+var csvHeader = []string{"variable", "service", "value", "layer", "file", "line", "overridden", "secret"}
+
+// writeCSV writes one row per variable with its project-wide value (service
+// empty), plus one row per compose service that sets it
+func writeCSV(w io.Writer, r *resolver.Resolution, _ Options) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	services := r.Services()
+	for _, v := range r.Variables {
+		if err := cw.Write(csvRow(v, "", v.FinalFrom)); err != nil {
+			return err
+		}
+		for _, svc := range services {
+			if !receives(v, svc) {
+				continue
+			}
+			src, _ := v.ForService(svc)
+			if err := cw.Write(csvRow(v, svc, src)); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRow(v *resolver.Variable, service string, src resolver.Source) []string {
+	line := ""
+	if src.Line > 0 {
+		line = strconv.Itoa(src.Line)
+	}
+	return []string{
+		v.Name,
+		service,
+		src.Value,
+		src.Layer.String(),
+		src.File,
+		line,
+		strconv.FormatBool(v.Overridden),
+		strconv.FormatBool(v.Secret),
+	}
+}
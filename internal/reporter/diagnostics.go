@@ -0,0 +1,60 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/envmerge/internal/lint"
+)
+
+// FormatDiagnosticsText renders lint findings one per line, compiler style
+func FormatDiagnosticsText(diags []lint.Diagnostic) string {
+	var sb strings.Builder
+
+	counts := map[lint.Severity]int{}
+	for _, d := range diags {
+		counts[d.Severity]++
+
+		sev := d.Severity.String()
+		switch d.Severity {
+		case lint.SeverityError:
+			sev = color.RedString(sev)
+		case lint.SeverityWarning:
+			sev = color.YellowString(sev)
+		default:
+			sev = color.CyanString(sev)
+		}
+
+		rule := fmt.Sprintf("[%s]", d.RuleID)
+		if d.Fixable {
+			rule += " (fixable)"
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s %s %s\n",
+			d.Location, sev, d.Message, color.HiBlackString(rule)))
+	}
+
+	if len(diags) == 0 {
+		sb.WriteString(color.GreenString("✅ No lint problems found\n"))
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%d problem(s): %d error(s), %d warning(s), %d info\n",
+		len(diags), counts[lint.SeverityError], counts[lint.SeverityWarning], counts[lint.SeverityInfo]))
+	return sb.String()
+}
+
+// FormatDiagnosticsJSON renders lint findings as a JSON array
+func FormatDiagnosticsJSON(diags []lint.Diagnostic) (string, error) {
+	out := make([]JSONDiagnostic, 0, len(diags))
+	for _, d := range diags {
+		out = append(out, newJSONDiagnostic(d))
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
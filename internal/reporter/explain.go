@@ -0,0 +1,205 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/stackgen-cli/envmerge/internal/explain"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// FormatExplainText renders an explanation as a narrative for the terminal
+func FormatExplainText(e *explain.Explanation) string {
+	var sb strings.Builder
+
+	bold := color.New(color.Bold).SprintFunc()
+	dim := color.New(color.FgHiBlack).SprintFunc()
+
+	sb.WriteString(bold(e.Variable))
+	if e.Secret {
+		sb.WriteString(" 🔒")
+	}
+	if e.Service != "" {
+		sb.WriteString(dim(fmt.Sprintf(" (service: %s)", e.Service)))
+	}
+	sb.WriteString("\n")
+	if e.Description != "" {
+		sb.WriteString(dim("  # "+e.Description) + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("  final: %s\n", displayValue(e.FinalFrom)))
+	sb.WriteString(fmt.Sprintf("  from:  %s\n\n", e.FinalFrom.Label()))
+
+	sb.WriteString(color.CyanString("Files checked\n"))
+	for _, c := range e.Candidates {
+		mark, detail := dim("·"), dim("not defined")
+		if c.Defined {
+			mark, detail = color.GreenString("✓"), "defined"
+			if len(c.Lines) > 0 {
+				detail = fmt.Sprintf("line %s", joinLines(c.Lines))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s %s\n", mark, c.File, dim("["+c.Layer.String()+"]"), detail))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(color.CyanString("Definitions (lowest precedence first)\n"))
+	for i, d := range e.Definitions {
+		marker := " "
+		if d.Wins {
+			marker = color.GreenString("→")
+		}
+		sb.WriteString(fmt.Sprintf("  %s %d. %s\n", marker, i+1, d.Source.Label()))
+		sb.WriteString(fmt.Sprintf("       %s\n", d.Snippet))
+		if d.Source.Unresolvable {
+			sb.WriteString(dim("       value "+unresolvableLabel) + "\n")
+		}
+		if d.Wins {
+			sb.WriteString(color.GreenString("       wins: %s\n", d.Reason))
+		} else {
+			sb.WriteString(dim("       loses: "+d.Reason) + "\n")
+		}
+	}
+	sb.WriteString("\n")
+
+	if len(e.Steps) > 0 {
+		sb.WriteString(color.CyanString("Interpolation\n"))
+		for _, s := range e.Steps {
+			switch {
+			case s.Missing:
+				sb.WriteString(fmt.Sprintf("  %s → %s\n", s.Reference, color.YellowString("undefined, expands to empty")))
+			case s.Default:
+				sb.WriteString(fmt.Sprintf("  %s → %q %s\n", s.Reference, s.Value, dim("(default)")))
+			default:
+				sb.WriteString(fmt.Sprintf("  %s → %q %s\n", s.Reference, s.Value, dim("from "+s.From.Label())))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("  expanded: %s\n\n", e.Expanded))
+	}
+
+	sb.WriteString(color.CyanString("Services\n"))
+	if len(e.Services) == 0 {
+		sb.WriteString(dim("  not passed to any compose service\n"))
+	}
+	for _, s := range e.Services {
+		sb.WriteString(fmt.Sprintf("  %s: %s %s\n", s.Service, displayValue(s.From), dim("from "+s.From.Label())))
+	}
+
+	return sb.String()
+}
+
+// displayValue renders a source value, spelling out empty and unresolvable ones
+func displayValue(s resolver.Source) string {
+	switch {
+	case s.Unresolvable:
+		return color.HiBlackString(unresolvableLabel)
+	case s.Value == "":
+		return color.HiBlackString("(empty)")
+	default:
+		return s.Value
+	}
+}
+
+// FormatExplainJSON renders an explanation as JSON
+func FormatExplainJSON(e *explain.Explanation) (string, error) {
+	type jsonSource struct {
+		Layer        string `json:"layer"`
+		File         string `json:"file,omitempty"`
+		Line         int    `json:"line,omitempty"`
+		Service      string `json:"service,omitempty"`
+		Value        string `json:"value"`
+		Encrypted    bool   `json:"encrypted,omitempty"`
+		Unresolvable bool   `json:"unresolvable,omitempty"`
+	}
+	source := func(s resolver.Source) jsonSource {
+		return jsonSource{
+			Layer:        s.Layer.String(),
+			File:         s.File,
+			Line:         s.Line,
+			Service:      s.Service,
+			Value:        s.Value,
+			Encrypted:    s.Encrypted,
+			Unresolvable: s.Unresolvable,
+		}
+	}
+
+	type jsonCandidate struct {
+		File    string `json:"file"`
+		Layer   string `json:"layer"`
+		Defined bool   `json:"defined"`
+		Lines   []int  `json:"lines,omitempty"`
+	}
+	type jsonDefinition struct {
+		Source  jsonSource `json:"source"`
+		Snippet string     `json:"snippet"`
+		Wins    bool       `json:"wins"`
+		Reason  string     `json:"reason"`
+	}
+	type jsonStep struct {
+		Reference string      `json:"reference"`
+		Name      string      `json:"name"`
+		Value     string      `json:"value"`
+		From      *jsonSource `json:"from,omitempty"`
+		Default   bool        `json:"default,omitempty"`
+		Missing   bool        `json:"missing,omitempty"`
+	}
+	type jsonService struct {
+		Service string     `json:"service"`
+		Value   string     `json:"value"`
+		From    jsonSource `json:"from"`
+	}
+	type jsonExplanation struct {
+		Variable      string           `json:"variable"`
+		Service       string           `json:"service,omitempty"`
+		Description   string           `json:"description,omitempty"`
+		Secret        bool             `json:"secret,omitempty"`
+		FinalValue    string           `json:"final_value"`
+		FinalFrom     jsonSource       `json:"final_from"`
+		Candidates    []jsonCandidate  `json:"candidates"`
+		Definitions   []jsonDefinition `json:"definitions"`
+		Interpolation []jsonStep       `json:"interpolation,omitempty"`
+		Expanded      string           `json:"expanded_value"`
+		Services      []jsonService    `json:"services"`
+	}
+
+	out := jsonExplanation{
+		Variable:    e.Variable,
+		Service:     e.Service,
+		Description: e.Description,
+		Secret:      e.Secret,
+		FinalValue:  e.FinalValue,
+		FinalFrom:   source(e.FinalFrom),
+		Candidates:  []jsonCandidate{},
+		Definitions: []jsonDefinition{},
+		Expanded:    e.Expanded,
+		Services:    []jsonService{},
+	}
+	for _, c := range e.Candidates {
+		out.Candidates = append(out.Candidates, jsonCandidate{
+			File: c.File, Layer: c.Layer.String(), Defined: c.Defined, Lines: c.Lines,
+		})
+	}
+	for _, d := range e.Definitions {
+		out.Definitions = append(out.Definitions, jsonDefinition{
+			Source: source(d.Source), Snippet: d.Snippet, Wins: d.Wins, Reason: d.Reason,
+		})
+	}
+	for _, s := range e.Steps {
+		js := jsonStep{Reference: s.Reference, Name: s.Name, Value: s.Value, Default: s.Default, Missing: s.Missing}
+		if s.From != nil {
+			from := source(*s.From)
+			js.From = &from
+		}
+		out.Interpolation = append(out.Interpolation, js)
+	}
+	for _, s := range e.Services {
+		out.Services = append(out.Services, jsonService{Service: s.Service, Value: s.Value, From: source(s.From)})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,140 @@
+package reporter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stackgen-cli/envmerge/internal/lint"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// RuleInfo describes a rule for formats that carry rule metadata
+type RuleInfo struct {
+	ID          string
+	Description string
+	Severity    lint.Severity
+}
+
+// LintRules returns metadata for every registered lint rule
+func LintRules() []RuleInfo {
+	var rules []RuleInfo
+	for _, r := range lint.Rules() {
+		rules = append(rules, RuleInfo{ID: r.ID, Description: r.Description, Severity: r.Severity})
+	}
+	return rules
+}
+
+// Scan finding rule IDs
+const (
+	RuleOverride     = "override"
+	RuleUndefined    = "undefined"
+	RuleSchema       = "schema"
+	RuleDuplicate    = "duplicate-key"
+	RulePlainSecret  = "plaintext-secret"
+	RuleUnresolvable = "unresolvable"
+	RuleScanWarning  = "resolution-warning"
+)
+
+// ScanRules returns metadata for the findings ScanDiagnostics reports
+func ScanRules() []RuleInfo {
+	return []RuleInfo{
+		{RuleDuplicate, "A key is defined more than once in the same file", lint.SeverityWarning},
+		{RuleOverride, "A variable is set to different values in several layers", lint.SeverityInfo},
+		{RulePlainSecret, "A secret value is stored unencrypted in an env file", lint.SeverityInfo},
+		{RuleScanWarning, "A file could not be read, parsed or decrypted", lint.SeverityWarning},
+		{RuleSchema, "A value violates the schema", lint.SeverityError},
+		{RuleUndefined, "A variable is referenced but never given a value", lint.SeverityError},
+		{RuleUnresolvable, "An encrypted value could not be decrypted", lint.SeverityWarning},
+	}
+}
+
+// ScanDiagnostics turns the findings of a scan into diagnostics, located at
+// the source responsible. Messages never include values, which may be secret
+func ScanDiagnostics(r *resolver.Resolution) []lint.Diagnostic {
+	var diags []lint.Diagnostic
+	seen := make(map[string]bool) // A shared env_file yields one source per service
+	add := func(rule string, sev lint.Severity, variable, message string, src resolver.Source) {
+		key := fmt.Sprintf("%s|%s|%s|%d", rule, variable, src.File, src.Line)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		diags = append(diags, lint.Diagnostic{
+			RuleID:   rule,
+			Severity: sev,
+			Message:  message,
+			Location: sourceDiagnosticLocation(src, r.Path),
+			Variable: variable,
+		})
+	}
+
+	for _, w := range r.Warnings {
+		diags = append(diags, lint.Diagnostic{
+			RuleID:   RuleScanWarning,
+			Severity: lint.SeverityWarning,
+			Message:  w,
+			Location: lint.Location{File: r.Path},
+		})
+	}
+
+	for _, d := range r.Duplicates {
+		add(RuleDuplicate, lint.SeverityWarning, d.Variable,
+			fmt.Sprintf("%s is defined on lines %s; line %d takes effect", d.Variable, joinLines(d.Lines), d.Effective),
+			resolver.Source{File: d.File, Line: d.Effective})
+	}
+
+	for _, v := range r.Violations {
+		add(RuleSchema, lint.SeverityError, v.Variable,
+			fmt.Sprintf("%s: %s [%s]", v.Variable, v.Message, v.Rule), v.Source)
+	}
+
+	undefined := make(map[string]bool)
+	for _, name := range r.UndefinedVars() {
+		undefined[name] = true
+	}
+
+	for _, v := range r.Variables {
+		switch {
+		case undefined[v.Name]:
+			add(RuleUndefined, lint.SeverityError, v.Name,
+				fmt.Sprintf("%s is referenced but never given a value", v.Name), v.FinalFrom)
+		case v.FinalFrom.Unresolvable:
+			add(RuleUnresolvable, lint.SeverityWarning, v.Name,
+				fmt.Sprintf("%s is encrypted and could not be decrypted", v.Name), v.FinalFrom)
+		case v.Overridden:
+			add(RuleOverride, lint.SeverityInfo, v.Name,
+				fmt.Sprintf("%s is set in %d places with different values; %s wins", v.Name, len(v.Chain), v.FinalFrom.Label()),
+				v.FinalFrom)
+		}
+
+		if v.Secret {
+			for _, src := range v.Chain {
+				if src.Line > 0 && src.Value != "" && !src.Encrypted && src.Layer != resolver.LayerEnvExample {
+					add(RulePlainSecret, lint.SeverityInfo, v.Name,
+						fmt.Sprintf("%s holds a secret in plain text", v.Name), src)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Location, diags[j].Location
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return diags[i].RuleID < diags[j].RuleID
+	})
+	return diags
+}
+
+// sourceDiagnosticLocation points at a source's file; values from the OS
+// environment have no file, so they are reported against the scanned path
+func sourceDiagnosticLocation(src resolver.Source, path string) lint.Location {
+	if src.File == "" || src.Layer == resolver.LayerOSEnv {
+		return lint.Location{File: path}
+	}
+	return lint.Location{File: src.File, Line: src.Line, Column: src.Column}
+}
@@ -0,0 +1,85 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// Options carries what some formats need beyond the resolution
+type Options struct {
+	Version string      // envmerge version, for formats that name the tool
+	Compare *Comparison // Adds a compare view to formats that have one
+}
+
+// Formatter writes a resolution to w in one output format
+type Formatter func(w io.Writer, r *resolver.Resolution, opts Options) error
+
+var formatters = map[string]Formatter{
+	"text":     stringFormatter(FormatText),
+	"json":     stringFormatter(FormatJSON),
+	"yaml":     stringFormatter(FormatYAML),
+	"toml":     stringFormatter(FormatTOML),
+	"csv":      writeCSV,
+	"ndjson":   writeNDJSON,
+	"markdown": stringFormatter(FormatMarkdown),
+	"html": func(w io.Writer, r *resolver.Resolution, opts Options) error {
+		out, err := FormatHTML(r, opts.Compare)
+		return writeLine(w, out, err)
+	},
+	"sarif": func(w io.Writer, r *resolver.Resolution, opts Options) error {
+		out, err := FormatSARIF(ScanRules(), ScanDiagnostics(r), opts.Version)
+		return writeLine(w, out, err)
+	},
+	"junit": func(w io.Writer, r *resolver.Resolution, opts Options) error {
+		var names []string
+		for _, v := range r.Variables {
+			names = append(names, v.Name)
+		}
+		out, err := FormatJUnitByVariable("envmerge scan", names, ScanDiagnostics(r))
+		return writeLine(w, out, err)
+	},
+	"github": func(w io.Writer, r *resolver.Resolution, opts Options) error {
+		_, err := io.WriteString(w, FormatGitHub(ScanDiagnostics(r)))
+		return err
+	},
+}
+
+// Register adds or replaces a format
+func Register(name string, f Formatter) {
+	formatters[name] = f
+}
+
+// Lookup returns the formatter registered as name
+func Lookup(name string) (Formatter, bool) {
+	f, ok := formatters[name]
+	return f, ok
+}
+
+// Formats lists the registered format names, sorted
+func Formats() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stringFormatter adapts a format that builds its whole output in memory
+func stringFormatter(format func(*resolver.Resolution) (string, error)) Formatter {
+	return func(w io.Writer, r *resolver.Resolution, _ Options) error {
+		out, err := format(r)
+		return writeLine(w, out, err)
+	}
+}
+
+func writeLine(w io.Writer, out string, err error) error {
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, out)
+	return err
+}
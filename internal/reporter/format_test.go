@@ -0,0 +1,135 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"gopkg.in/yaml.v3"
+)
+
+func projectResolution(t *testing.T) *resolver.Resolution {
+	t.Helper()
+	r, err := resolver.Resolve(filepath.Join("testdata", "project"))
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	return r
+}
+
+func TestFormats_Registered(t *testing.T) {
+	for _, name := range []string{"text", "json", "yaml", "toml", "csv", "ndjson", "markdown", "html", "sarif", "junit", "github"} {
+		if _, ok := Lookup(name); !ok {
+			t.Errorf("format %s is not registered", name)
+		}
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("Lookup of an unknown format should fail")
+	}
+}
+
+func TestFormatYAML_SameStructureAsJSON(t *testing.T) {
+	r := projectResolution(t)
+	j, err := FormatJSON(r)
+	if err != nil {
+		t.Fatalf("FormatJSON failed: %v", err)
+	}
+	y, err := FormatYAML(r)
+	if err != nil {
+		t.Fatalf("FormatYAML failed: %v", err)
+	}
+
+	var fromJSON, fromYAML interface{}
+	if err := json.Unmarshal([]byte(j), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte(y), &fromYAML); err != nil {
+		t.Fatalf("invalid YAML: %v", err)
+	}
+	// Round-trip through JSON so numbers and maps have the same types
+	data, err := json.Marshal(fromYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML = nil
+	json.Unmarshal(data, &fromYAML)
+
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("YAML and JSON differ:\n%s", y)
+	}
+	if !strings.Contains(y, `schema_version: "1"`) {
+		t.Errorf("version should stay a string:\n%s", y)
+	}
+}
+
+func TestFormatTOML_Golden(t *testing.T) {
+	out, err := FormatTOML(projectResolution(t))
+	if err != nil {
+		t.Fatalf("FormatTOML failed: %v", err)
+	}
+	golden(t, "scan.toml", out)
+}
+
+func TestTOMLString_Escaping(t *testing.T) {
+	if got := tomlString("a \"b\"\\\n\x01"); got != `"a \"b\"\\\n\u0001"` {
+		t.Errorf("tomlString = %s", got)
+	}
+	if got := tomlKey("a.b"); got != `"a.b"` {
+		t.Errorf("tomlKey = %s", got)
+	}
+}
+
+func TestWriteCSV_RowPerService(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, projectResolution(t), Options{}); err != nil {
+		t.Fatalf("writeCSV failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if !reflect.DeepEqual(rows[0], csvHeader) {
+		t.Errorf("header = %v", rows[0])
+	}
+
+	var got []string
+	for _, row := range rows[1:] {
+		if row[0] == "DB_HOST" {
+			got = append(got, strings.Join(row, "|"))
+		}
+	}
+	want := []string{
+		"DB_HOST||db|compose env_file|" + filepath.Join("testdata", "project", "api.env") + "|1|true|false",
+		"DB_HOST|api|db|compose env_file|" + filepath.Join("testdata", "project", "api.env") + "|1|true|false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DB_HOST rows = %v, want %v", got, want)
+	}
+}
+
+func TestWriteNDJSON_OneVariablePerLine(t *testing.T) {
+	r := projectResolution(t)
+	var buf bytes.Buffer
+	if err := writeNDJSON(&buf, r, Options{}); err != nil {
+		t.Fatalf("writeNDJSON failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(r.Variables) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(r.Variables))
+	}
+	for i, line := range lines {
+		var v JSONVariable
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i+1, err)
+		}
+		if v.Name != r.Variables[i].Name {
+			t.Errorf("line %d = %s, want %s", i+1, v.Name, r.Variables[i].Name)
+		}
+	}
+}
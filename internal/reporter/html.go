@@ -0,0 +1,361 @@
+package reporter
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// Comparison is a compare result with the names of both sides, for
+// formats that show it alongside the resolution
+type Comparison struct {
+	First  string
+	Second string
+	Result *resolver.CompareResult
+}
+
+type htmlSource struct {
+	Location  string
+	Layer     string
+	Service   string
+	Value     string
+	Muted     bool // Value is a placeholder such as (empty)
+	Wins      bool
+	Encrypted bool
+}
+
+type htmlVariable struct {
+	Name        string
+	Description string
+	Value       string
+	Muted       bool
+	From        string
+	Secret      bool
+	Overridden  bool
+	Undefined   bool
+	Chain       []htmlSource
+}
+
+type htmlService struct {
+	Name      string
+	Variables []htmlVariable
+}
+
+type htmlReport struct {
+	Path         string
+	EnvFiles     []string
+	ComposeFiles []string
+	Variables    []htmlVariable
+	Overridden   int
+	Secrets      int
+	Services     []htmlService
+	Conflicts    []htmlVariable
+	Warnings     []string
+	Violations   []resolver.Violation
+	Duplicates   []resolver.Duplicate
+	Compare      *Comparison
+}
+
+// FormatHTML generates a self-contained HTML report with a searchable
+// variable table, precedence chains, per-service tabs and conflicts. cmp
+// adds a compare tab and may be nil. Values are printed as given, so
+// redact the resolution first
+func FormatHTML(r *resolver.Resolution, cmp *Comparison) (string, error) {
+	report := htmlReport{
+		Path:         r.Path,
+		EnvFiles:     r.EnvFiles,
+		ComposeFiles: r.ComposeFiles,
+		Warnings:     r.Warnings,
+		Violations:   r.Violations,
+		Duplicates:   r.Duplicates,
+		Compare:      cmp,
+	}
+
+	undefined := make(map[string]bool)
+	for _, name := range r.UndefinedVars() {
+		undefined[name] = true
+	}
+
+	for _, v := range r.Variables {
+		hv := newHTMLVariable(v, v.FinalFrom, undefined[v.Name])
+		report.Variables = append(report.Variables, hv)
+		if v.Overridden {
+			report.Overridden++
+			report.Conflicts = append(report.Conflicts, hv)
+		}
+		if v.Secret {
+			report.Secrets++
+		}
+	}
+
+	for _, svc := range r.Services() {
+		s := htmlService{Name: svc}
+		for _, v := range r.Variables {
+			if !receives(v, svc) {
+				continue
+			}
+			src, _ := v.ForService(svc)
+			s.Variables = append(s.Variables, newHTMLVariable(v, src, undefined[v.Name]))
+		}
+		report.Services = append(report.Services, s)
+	}
+
+	var sb strings.Builder
+	if err := htmlTemplate.Execute(&sb, report); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// newHTMLVariable renders v with final as its effective source
+func newHTMLVariable(v *resolver.Variable, final resolver.Source, undefined bool) htmlVariable {
+	hv := htmlVariable{
+		Name:        v.Name,
+		Description: v.Description,
+		From:        final.Label(),
+		Secret:      v.Secret,
+		Overridden:  v.Overridden,
+		Undefined:   undefined,
+	}
+	hv.Value, hv.Muted = htmlValue(final)
+
+	for i := len(v.Chain) - 1; i >= 0; i-- {
+		src := v.Chain[i]
+		hs := htmlSource{
+			Location:  src.Location(),
+			Layer:     src.Layer.String(),
+			Service:   src.Service,
+			Wins:      src == final,
+			Encrypted: src.Encrypted,
+		}
+		hs.Value, hs.Muted = htmlValue(src)
+		hv.Chain = append(hv.Chain, hs)
+	}
+	return hv
+}
+
+// htmlValue is displayValue without terminal colors
+func htmlValue(s resolver.Source) (string, bool) {
+	switch {
+	case s.Unresolvable:
+		return unresolvableLabel, true
+	case s.IsInline && s.Value == "":
+		return "(from the shell)", true
+	case s.Value == "":
+		return "(empty)", true
+	default:
+		return s.Value, false
+	}
+}
+
+// receives reports whether a compose service sets v itself
+func receives(v *resolver.Variable, service string) bool {
+	for _, src := range v.Chain {
+		if src.Service == service {
+			return true
+		}
+	}
+	return false
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"plural": func(n int, word string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, word)
+		}
+		return fmt.Sprintf("%d %ss", n, word)
+	},
+	"lines": joinLines,
+}).Parse(htmlPage))
+
+// htmlPage holds all markup, styles and script, so the report works offline
+const htmlPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="envmerge">
+<title>Environment Resolution Report: {{.Path}}</title>
+<style>
+  :root { --fg: #1f2328; --muted: #656d76; --border: #d0d7de; --bg: #f6f8fa; --accent: #0969da; --warn: #9a6700; --bad: #cf222e; --good: #1a7f37; }
+  * { box-sizing: border-box; }
+  body { margin: 0; padding: 24px; font: 14px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: var(--fg); }
+  h1 { font-size: 22px; margin: 0 0 4px; }
+  h2 { font-size: 16px; margin: 24px 0 8px; }
+  code, .value, .loc { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 13px; }
+  .muted { color: var(--muted); }
+  .summary { display: flex; flex-wrap: wrap; gap: 12px; margin: 16px 0; }
+  .summary div { background: var(--bg); border: 1px solid var(--border); border-radius: 6px; padding: 8px 14px; }
+  .summary b { display: block; font-size: 20px; }
+  nav { display: flex; flex-wrap: wrap; gap: 4px; border-bottom: 1px solid var(--border); margin-top: 16px; }
+  nav button { border: 1px solid transparent; border-bottom: none; background: none; padding: 8px 14px; cursor: pointer; font: inherit; color: var(--muted); border-radius: 6px 6px 0 0; }
+  nav button.active { border-color: var(--border); background: #fff; color: var(--fg); margin-bottom: -1px; }
+  section { display: none; padding-top: 16px; }
+  section.active { display: block; }
+  .controls { display: flex; gap: 8px; margin-bottom: 12px; }
+  .controls input { flex: 1; max-width: 420px; padding: 6px 10px; border: 1px solid var(--border); border-radius: 6px; font: inherit; }
+  .controls select { padding: 6px; border: 1px solid var(--border); border-radius: 6px; font: inherit; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { text-align: left; vertical-align: top; padding: 6px 10px; border-bottom: 1px solid var(--border); }
+  th { background: var(--bg); font-weight: 600; }
+  td.value { word-break: break-all; white-space: pre-wrap; max-width: 520px; }
+  details summary { cursor: pointer; color: var(--accent); }
+  ol.chain { margin: 6px 0 0; padding-left: 20px; }
+  ol.chain li.wins { font-weight: 600; }
+  ol.chain li.wins::marker { content: "→ "; }
+  .tag { display: inline-block; font-size: 11px; padding: 0 6px; border-radius: 10px; border: 1px solid var(--border); margin-left: 4px; color: var(--muted); }
+  .tag.secret { color: var(--warn); border-color: var(--warn); }
+  .tag.override { color: var(--accent); border-color: var(--accent); }
+  .tag.undefined { color: var(--bad); border-color: var(--bad); }
+  ul.findings li { margin-bottom: 4px; }
+  .bad { color: var(--bad); }
+  .warn { color: var(--warn); }
+  .good { color: var(--good); }
+</style>
+</head>
+<body>
+<h1>Environment Resolution Report</h1>
+<div class="muted">Path: <code>{{.Path}}</code></div>
+
+<div class="summary">
+  <div><b>{{len .EnvFiles}}</b>env files</div>
+  <div><b>{{len .ComposeFiles}}</b>compose files</div>
+  <div><b>{{len .Variables}}</b>variables</div>
+  <div><b>{{.Overridden}}</b>with overrides</div>
+  <div><b>{{.Secrets}}</b>secrets</div>
+  <div><b>{{len .Services}}</b>services</div>
+</div>
+
+<nav>
+  <button class="active" data-tab="variables">Variables</button>
+  {{range $i, $s := .Services}}<button data-tab="service-{{$i}}">{{$s.Name}}</button>
+  {{end}}<button data-tab="conflicts">Conflicts ({{len .Conflicts}})</button>
+  {{if .Compare}}<button data-tab="compare">Compare</button>
+  {{end}}{{if or .Warnings .Violations .Duplicates}}<button data-tab="findings">Findings</button>{{end}}
+</nav>
+
+<section id="variables" class="active">
+  <div class="controls">
+    <input type="search" placeholder="Filter by name, value or source" aria-label="Filter variables">
+    <select aria-label="Show">
+      <option value="">All variables</option>
+      <option value="overridden">With overrides</option>
+      <option value="secret">Secrets</option>
+      <option value="undefined">Undefined</option>
+    </select>
+  </div>
+  {{template "table" .Variables}}
+</section>
+
+{{range $i, $s := .Services}}
+<section id="service-{{$i}}">
+  <h2>Service {{$s.Name}}</h2>
+  <p class="muted">Variables set by the service's env_file or environment entries, with the value it receives.</p>
+  <div class="controls"><input type="search" placeholder="Filter by name, value or source" aria-label="Filter variables"></div>
+  {{template "table" $s.Variables}}
+</section>
+{{end}}
+
+<section id="conflicts">
+  {{if .Conflicts}}
+  <p class="muted">Variables set to different values in several places. The winning definition is marked with →.</p>
+  <table>
+    <thead><tr><th>Variable</th><th>Definitions, highest precedence first</th></tr></thead>
+    <tbody>
+    {{range .Conflicts}}
+      <tr><td><code>{{.Name}}</code>{{if .Secret}}<span class="tag secret">secret</span>{{end}}</td>
+      <td>{{template "chain" .Chain}}</td></tr>
+    {{end}}
+    </tbody>
+  </table>
+  {{else}}<p class="good">No variable is overridden.</p>{{end}}
+</section>
+
+{{with .Compare}}
+<section id="compare">
+  <h2><code>{{.First}}</code> vs <code>{{.Second}}</code></h2>
+  <div class="summary">
+    <div><b>{{len .Result.OnlyInFirst}}</b>only in {{.First}}</div>
+    <div><b>{{len .Result.OnlyInSecond}}</b>only in {{.Second}}</div>
+    <div><b>{{len .Result.Different}}</b>different</div>
+    <div><b>{{len .Result.Same}}</b>same</div>
+    {{if .Result.Ignored}}<div><b>{{len .Result.Ignored}}</b>ignored</div>{{end}}
+  </div>
+  {{if .Result.Different}}
+  <h2>Different values</h2>
+  <table>
+    <thead><tr><th>Variable</th><th>{{.First}}</th><th>{{.Second}}</th></tr></thead>
+    <tbody>
+    {{range .Result.Different}}<tr><td><code>{{.Name}}</code></td><td><span class="value">{{.FirstValue}}</span> <span class="loc muted">{{.FirstFrom.Location}}</span></td><td><span class="value">{{.SecondValue}}</span> <span class="loc muted">{{.SecondFrom.Location}}</span></td></tr>
+    {{end}}
+    </tbody>
+  </table>
+  {{end}}
+  {{if .Result.OnlyInFirst}}<h2>Only in {{.First}}</h2>
+  <ul>{{range .Result.OnlyInFirst}}<li><code>{{.}}</code> <span class="loc muted">{{(index $.Compare.Result.FirstFrom .).Location}}</span></li>{{end}}</ul>{{end}}
+  {{if .Result.OnlyInSecond}}<h2>Only in {{.Second}}</h2>
+  <ul>{{range .Result.OnlyInSecond}}<li><code>{{.}}</code> <span class="loc muted">{{(index $.Compare.Result.SecondFrom .).Location}}</span></li>{{end}}</ul>{{end}}
+</section>
+{{end}}
+
+{{if or .Warnings .Violations .Duplicates}}
+<section id="findings">
+  {{if .Violations}}<h2 class="bad">Schema violations</h2>
+  <ul class="findings">{{range .Violations}}<li><code>{{.Variable}}</code>{{if .Service}} (service {{.Service}}){{end}}: {{.Message}} [{{.Rule}}]{{if .Source.File}} <span class="loc muted">{{.Source.Location}}</span>{{end}}</li>{{end}}</ul>{{end}}
+  {{if .Duplicates}}<h2 class="warn">Duplicate keys</h2>
+  <ul class="findings">{{range .Duplicates}}<li><code>{{.Variable}}</code> in <span class="loc">{{.File}}</span>: lines {{lines .Lines}} (line {{.Effective}} takes effect)</li>{{end}}</ul>{{end}}
+  {{if .Warnings}}<h2 class="warn">Warnings</h2>
+  <ul class="findings">{{range .Warnings}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</section>
+{{end}}
+
+<script>
+(function () {
+  var tabs = document.querySelectorAll("nav button");
+  tabs.forEach(function (tab) {
+    tab.addEventListener("click", function () {
+      tabs.forEach(function (t) { t.classList.toggle("active", t === tab); });
+      document.querySelectorAll("section").forEach(function (s) {
+        s.classList.toggle("active", s.id === tab.dataset.tab);
+      });
+    });
+  });
+
+  document.querySelectorAll("section").forEach(function (section) {
+    var search = section.querySelector("input[type=search]");
+    var show = section.querySelector("select");
+    if (!search) { return; }
+    function apply() {
+      var q = search.value.toLowerCase();
+      var only = show ? show.value : "";
+      section.querySelectorAll("tbody tr").forEach(function (row) {
+        var match = row.textContent.toLowerCase().indexOf(q) !== -1 && (!only || row.dataset[only] === "true");
+        row.hidden = !match;
+      });
+    }
+    search.addEventListener("input", apply);
+    if (show) { show.addEventListener("change", apply); }
+  });
+})();
+</script>
+</body>
+</html>
+{{define "table"}}<table>
+    <thead><tr><th>Variable</th><th>Value</th><th>From</th></tr></thead>
+    <tbody>
+    {{range .}}
+      <tr data-overridden="{{.Overridden}}" data-secret="{{.Secret}}" data-undefined="{{.Undefined}}">
+        <td><code>{{.Name}}</code>{{if .Secret}}<span class="tag secret">secret</span>{{end}}{{if .Overridden}}<span class="tag override">overridden</span>{{end}}{{if .Undefined}}<span class="tag undefined">undefined</span>{{end}}
+          {{with .Description}}<div class="muted">{{.}}</div>{{end}}</td>
+        <td class="value{{if .Muted}} muted{{end}}">{{.Value}}</td>
+        <td><span class="loc">{{.From}}</span>
+          {{if gt (len .Chain) 1}}<details><summary>{{plural (len .Chain) "definition"}}</summary>{{template "chain" .Chain}}</details>{{end}}</td>
+      </tr>
+    {{end}}
+    </tbody>
+  </table>{{end}}
+{{define "chain"}}<ol class="chain">{{range .}}
+  <li{{if .Wins}} class="wins"{{end}}><span class="loc">{{.Location}}</span>{{if .Service}} <span class="tag">{{.Service}}</span>{{end}} = <span class="value{{if .Muted}} muted{{end}}">{{.Value}}</span>{{if .Encrypted}}<span class="tag">encrypted</span>{{end}}</li>{{end}}
+</ol>{{end}}`
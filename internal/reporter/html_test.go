@@ -0,0 +1,66 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+func TestFormatHTML_Report(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=3000\nSTRIPE_KEY=sk_live_abcdefghijklmnop1234\nGREETING=<b>hi</b>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env.local"), []byte("PORT=4000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "compose.yml"), []byte("services:\n  api:\n    environment:\n      PORT: \"5000\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	secrets.Mark(r)
+	r = secrets.Redact(r)
+
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, ".env"), []byte("PORT=3000\nONLY_THERE=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := resolver.Resolve(other)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	out, err := FormatHTML(r, &Comparison{First: "dev", Second: "staging", Result: resolver.Compare(r, second)})
+	if err != nil {
+		t.Fatalf("FormatHTML failed: %v", err)
+	}
+
+	if strings.Contains(out, "sk_live_abcdefghijklmnop1234") {
+		t.Error("report should not contain the secret value")
+	}
+	if strings.Contains(out, "<b>hi</b>") || !strings.Contains(out, "&lt;b&gt;hi&lt;/b&gt;") {
+		t.Error("values should be HTML-escaped")
+	}
+	if strings.Contains(out, "<link") || strings.Contains(out, "src=") {
+		t.Error("report should not load external assets")
+	}
+	for _, want := range []string{
+		`data-tab="service-0">api</button>`,
+		"Conflicts (1)",
+		`<section id="compare">`,
+		"<code>ONLY_THERE</code>",
+		`<li class="wins"><span class="loc">` + filepath.Join(dir, "compose.yml") + `</span> <span class="tag">api</span> = <span class="value">5000</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %s", want)
+		}
+	}
+}
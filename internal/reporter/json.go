@@ -0,0 +1,239 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/stackgen-cli/envmerge/internal/lint"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// SchemaVersion is the version of the JSON output. Adding fields keeps it;
+// renaming, removing or changing the meaning of a field bumps it
+const SchemaVersion = "1"
+
+// JSONReport is the document written by scan --format json
+type JSONReport struct {
+	SchemaVersion  string              `json:"schema_version"`
+	Path           string              `json:"path"`
+	EnvFiles       []string            `json:"env_files"`
+	ComposeFiles   []string            `json:"compose_files"`
+	Variables      []JSONVariable      `json:"variables"`
+	Undefined      []string            `json:"undefined"`   // Referenced but never given a value
+	Services       []JSONService       `json:"services"`    // Per compose service, in name order
+	Diagnostics    []JSONDiagnostic    `json:"diagnostics"` // Scan findings, as in --format sarif
+	Warnings       []string            `json:"warnings"`
+	Violations     []JSONViolation     `json:"violations"`
+	Duplicates     []JSONDuplicate     `json:"duplicates"`
+	EncryptedFiles []JSONEncryptedFile `json:"encrypted_files"`
+}
+
+// JSONSource is one place a value was set
+type JSONSource struct {
+	Layer        string `json:"layer"`
+	File         string `json:"file,omitempty"`
+	Line         int    `json:"line,omitempty"`
+	Column       int    `json:"column,omitempty"`
+	Service      string `json:"service,omitempty"`
+	Value        string `json:"value"`
+	Inline       bool   `json:"inline,omitempty"` // Set in a compose environment entry
+	Encrypted    bool   `json:"encrypted,omitempty"`
+	Unresolvable bool   `json:"unresolvable,omitempty"` // Encrypted and not decrypted; value is empty
+}
+
+// JSONVariable is a resolved variable with every definition
+type JSONVariable struct {
+	Name        string       `json:"name"`
+	FinalValue  string       `json:"final_value"`
+	FinalFrom   JSONSource   `json:"final_from"`
+	Overridden  bool         `json:"overridden"`
+	Chain       []JSONSource `json:"chain"`     // Lowest precedence first
+	Conflicts   []string     `json:"conflicts"` // Other non-empty values in the chain, sorted
+	Description string       `json:"description,omitempty"`
+	Secret      bool         `json:"secret,omitempty"`
+}
+
+// JSONService lists the variables a compose service sets itself
+type JSONService struct {
+	Name      string                `json:"name"`
+	Variables []JSONServiceVariable `json:"variables"`
+}
+
+// JSONServiceVariable is the value one service receives
+type JSONServiceVariable struct {
+	Name   string     `json:"name"`
+	Value  string     `json:"value"`
+	Source JSONSource `json:"source"`
+}
+
+// JSONDiagnostic is a finding located at the source responsible
+type JSONDiagnostic struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"` // error, warning or info
+	Message  string `json:"message"`
+	Variable string `json:"variable,omitempty"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Fixable  bool   `json:"fixable"`
+}
+
+// JSONViolation is a schema check failure
+type JSONViolation struct {
+	Variable string     `json:"variable"`
+	Service  string     `json:"service,omitempty"`
+	Rule     string     `json:"rule"`
+	Message  string     `json:"message"`
+	Source   JSONSource `json:"source"`
+}
+
+// JSONDuplicate is a key defined more than once in one file
+type JSONDuplicate struct {
+	Variable      string `json:"variable"`
+	File          string `json:"file"`
+	Lines         []int  `json:"lines"`
+	EffectiveLine int    `json:"effective_line"`
+}
+
+// JSONEncryptedFile summarizes an encrypted env file
+type JSONEncryptedFile struct {
+	File          string   `json:"file"`
+	Format        string   `json:"format"`
+	Decrypted     bool     `json:"decrypted"`
+	EncryptedKeys []string `json:"encrypted_keys"`
+	PlaintextKeys []string `json:"plaintext_keys"`
+}
+
+// NewJSONReport converts a resolution to the JSON output types. Lists are
+// never null, so consumers can rely on every field being present
+func NewJSONReport(r *resolver.Resolution) *JSONReport {
+	out := &JSONReport{
+		SchemaVersion:  SchemaVersion,
+		Path:           r.Path,
+		EnvFiles:       nonNil(r.EnvFiles),
+		ComposeFiles:   nonNil(r.ComposeFiles),
+		Variables:      []JSONVariable{},
+		Undefined:      nonNil(r.UndefinedVars()),
+		Services:       []JSONService{},
+		Diagnostics:    []JSONDiagnostic{},
+		Warnings:       nonNil(r.Warnings),
+		Violations:     []JSONViolation{},
+		Duplicates:     []JSONDuplicate{},
+		EncryptedFiles: []JSONEncryptedFile{},
+	}
+
+	for _, v := range r.Variables {
+		out.Variables = append(out.Variables, newJSONVariable(v))
+	}
+
+	for _, svc := range r.Services() {
+		js := JSONService{Name: svc, Variables: []JSONServiceVariable{}}
+		for _, v := range r.Variables {
+			if !receives(v, svc) {
+				continue
+			}
+			src, _ := v.ForService(svc)
+			js.Variables = append(js.Variables, JSONServiceVariable{Name: v.Name, Value: src.Value, Source: newJSONSource(src)})
+		}
+		out.Services = append(out.Services, js)
+	}
+
+	for _, d := range ScanDiagnostics(r) {
+		out.Diagnostics = append(out.Diagnostics, newJSONDiagnostic(d))
+	}
+
+	for _, v := range r.Violations {
+		out.Violations = append(out.Violations, JSONViolation{
+			Variable: v.Variable,
+			Service:  v.Service,
+			Rule:     v.Rule,
+			Message:  v.Message,
+			Source:   newJSONSource(v.Source),
+		})
+	}
+
+	for _, d := range r.Duplicates {
+		out.Duplicates = append(out.Duplicates, JSONDuplicate{
+			Variable:      d.Variable,
+			File:          d.File,
+			Lines:         d.Lines,
+			EffectiveLine: d.Effective,
+		})
+	}
+
+	for _, f := range r.EncryptedFiles {
+		out.EncryptedFiles = append(out.EncryptedFiles, JSONEncryptedFile{
+			File:          f.File,
+			Format:        f.Format,
+			Decrypted:     f.Decrypted,
+			EncryptedKeys: nonNil(f.Encrypted),
+			PlaintextKeys: nonNil(f.Plaintext),
+		})
+	}
+	return out
+}
+
+// FormatJSON generates JSON output
+func FormatJSON(r *resolver.Resolution) (string, error) {
+	data, err := json.MarshalIndent(NewJSONReport(r), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// writeNDJSON writes one JSONVariable per line as it goes, so large scans
+// can be consumed as a stream
+func writeNDJSON(w io.Writer, r *resolver.Resolution, _ Options) error {
+	enc := json.NewEncoder(w)
+	for _, v := range r.Variables {
+		if err := enc.Encode(newJSONVariable(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newJSONVariable(v *resolver.Variable) JSONVariable {
+	jv := JSONVariable{
+		Name:        v.Name,
+		FinalValue:  v.FinalValue,
+		FinalFrom:   newJSONSource(v.FinalFrom),
+		Overridden:  v.Overridden,
+		Chain:       []JSONSource{},
+		Conflicts:   nonNil(v.Conflicts),
+		Description: v.Description,
+		Secret:      v.Secret,
+	}
+	for _, s := range v.Chain {
+		jv.Chain = append(jv.Chain, newJSONSource(s))
+	}
+	return jv
+}
+
+func newJSONSource(s resolver.Source) JSONSource {
+	return JSONSource{
+		Layer:        s.Layer.String(),
+		File:         s.File,
+		Line:         s.Line,
+		Column:       s.Column,
+		Service:      s.Service,
+		Value:        s.Value,
+		Inline:       s.IsInline,
+		Encrypted:    s.Encrypted,
+		Unresolvable: s.Unresolvable,
+	}
+}
+
+func newJSONDiagnostic(d lint.Diagnostic) JSONDiagnostic {
+	return JSONDiagnostic{
+		Rule:     d.RuleID,
+		Severity: d.Severity.String(),
+		Message:  d.Message,
+		Variable: d.Variable,
+		File:     d.Location.File,
+		Line:     d.Location.Line,
+		Column:   d.Location.Column,
+		Fixable:  d.Fixable,
+	}
+}
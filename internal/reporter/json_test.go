@@ -0,0 +1,145 @@
+package reporter
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// golden compares got with testdata/golden/name, or rewrites it with -update
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run go test -update): %v", err)
+	}
+	if string(want) != got+"\n" {
+		t.Errorf("%s changed; if intended, bump SchemaVersion for breaking changes and run go test -update\ngot:\n%s", name, got)
+	}
+}
+
+func projectReport(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join("testdata", "project")
+	r, err := resolver.Resolve(path)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	s, err := schema.Discover(path, "")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	schema.Annotate(s, r)
+	secrets.Mark(r)
+	schema.Validate(s, r)
+
+	out, err := FormatJSON(secrets.Redact(r))
+	if err != nil {
+		t.Fatalf("FormatJSON failed: %v", err)
+	}
+	return out
+}
+
+func TestFormatJSON_Golden(t *testing.T) {
+	golden(t, "scan.json", projectReport(t))
+}
+
+func TestJSONSchema_Golden(t *testing.T) {
+	out, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	golden(t, "schema.json", out)
+}
+
+func TestJSONSchema_DescribesOutput(t *testing.T) {
+	out, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+	var s map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &s); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+
+	var report interface{}
+	if err := json.Unmarshal([]byte(projectReport(t)), &report); err != nil {
+		t.Fatalf("invalid report: %v", err)
+	}
+	checkSchema(t, "$", s, s, report)
+}
+
+// checkSchema validates the subset of JSON Schema that JSONSchema emits:
+// types, required properties and no properties the schema doesn't know
+func checkSchema(t *testing.T, at string, root, s map[string]interface{}, v interface{}) {
+	t.Helper()
+	if ref, ok := s["$ref"].(string); ok {
+		name := filepath.Base(ref)
+		s = root["$defs"].(map[string]interface{})[name].(map[string]interface{})
+	}
+
+	switch s["type"] {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: want object, got %T", at, v)
+			return
+		}
+		props := s["properties"].(map[string]interface{})
+		for _, name := range s["required"].([]interface{}) {
+			if _, ok := obj[name.(string)]; !ok {
+				t.Errorf("%s: missing required %s", at, name)
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p, ok := props[k].(map[string]interface{})
+			if !ok {
+				t.Errorf("%s: %s is not in the schema", at, k)
+				continue
+			}
+			checkSchema(t, at+"."+k, root, p, obj[k])
+		}
+	case "array":
+		list, ok := v.([]interface{})
+		if !ok {
+			t.Errorf("%s: want array, got %T", at, v)
+			return
+		}
+		for _, item := range list {
+			checkSchema(t, at+"[]", root, s["items"].(map[string]interface{}), item)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			t.Errorf("%s: want string, got %T", at, v)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int(n)) {
+			t.Errorf("%s: want integer, got %v", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			t.Errorf("%s: want boolean, got %T", at, v)
+		}
+	}
+}
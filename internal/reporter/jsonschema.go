@@ -0,0 +1,71 @@
+package reporter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the output of
+// FormatJSON. It is generated from the output types, so it cannot drift
+func JSONSchema() (string, error) {
+	defs := make(map[string]interface{})
+	root := schemaFor(reflect.TypeOf(JSONReport{}), defs)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "envmerge scan report"
+	root["description"] = "Output of envmerge scan --format json, schema version " + SchemaVersion
+	root["$defs"] = defs
+	root["properties"].(map[string]interface{})["schema_version"] = map[string]interface{}{
+		"type":  "string",
+		"const": SchemaVersion,
+	}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// schemaFor describes t; nested structs go into defs and are referenced
+func schemaFor(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaRef(t.Elem(), defs)}
+	}
+
+	props := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		props[name] = schemaRef(f.Type, defs)
+		if opts != "omitempty" {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+// schemaRef inlines simple types and refers to structs by name
+func schemaRef(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	if t.Kind() != reflect.Struct {
+		return schemaFor(t, defs)
+	}
+	name := strings.TrimPrefix(t.Name(), "JSON")
+	if _, ok := defs[name]; !ok {
+		defs[name] = nil // Placeholder against recursion
+		defs[name] = schemaFor(t, defs)
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
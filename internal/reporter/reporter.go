@@ -2,7 +2,6 @@
 package reporter
 
 import (
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -33,6 +32,53 @@ func FormatText(r *resolver.Resolution) (string, error) {
 		sb.WriteString("\n")
 	}
 
+	// Keys repeated within one file
+	if len(r.Duplicates) > 0 {
+		sb.WriteString(color.YellowString("⚠️  Duplicate Keys\n"))
+		for _, d := range r.Duplicates {
+			sb.WriteString(fmt.Sprintf("  • %s in %s: lines %s (line %d takes effect)\n",
+				d.Variable, d.File, joinLines(d.Lines), d.Effective))
+		}
+		sb.WriteString("\n")
+	}
+
+	// SOPS files
+	if len(r.EncryptedFiles) > 0 {
+		sb.WriteString(color.CyanString("🔐 Encrypted Files\n"))
+		for _, f := range r.EncryptedFiles {
+			state := "decrypted"
+			if !f.Decrypted {
+				state = "not decrypted"
+			}
+			sb.WriteString(fmt.Sprintf("  • %s (%s, %s): %d encrypted, %d plaintext\n",
+				f.File, f.Format, state, len(f.Encrypted), len(f.Plaintext)))
+			if len(f.Encrypted) > 0 {
+				sb.WriteString(color.HiBlackString("      encrypted: %s\n", strings.Join(f.Encrypted, ", ")))
+			}
+			if len(f.Plaintext) > 0 {
+				sb.WriteString(color.HiBlackString("      plaintext: %s\n", strings.Join(f.Plaintext, ", ")))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	// Schema violations
+	if len(r.Violations) > 0 {
+		sb.WriteString(color.RedString("❌ Schema Violations\n"))
+		for _, v := range r.Violations {
+			name := v.Variable
+			if v.Service != "" {
+				name = fmt.Sprintf("%s (service: %s)", v.Variable, v.Service)
+			}
+			sb.WriteString(fmt.Sprintf("  • %s: %s [%s]", name, v.Message, v.Rule))
+			if v.Source.File != "" {
+				sb.WriteString(fmt.Sprintf(" at %s", v.Source.Location()))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
 	// Variables with overrides first
 	overridden := []*resolver.Variable{}
 	clean := []*resolver.Variable{}
@@ -64,28 +110,60 @@ func FormatText(r *resolver.Resolution) (string, error) {
 	return sb.String(), nil
 }
 
+func joinLines(lines []int) string {
+	parts := make([]string, len(lines))
+	for i, n := range lines {
+		parts[i] = fmt.Sprintf("%d", n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// unresolvableLabel stands in for an encrypted value that could not be decrypted
+const unresolvableLabel = "(encrypted, not resolvable)"
+
+// nonNil keeps empty lists as [] rather than null in JSON
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// codeList renders names as inline code, comma separated
+func codeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = "`" + n + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func formatVariable(sb *strings.Builder, v *resolver.Variable, showChain bool) {
 	// Variable name
 	sb.WriteString(color.WhiteString(v.Name))
+	if v.Secret {
+		sb.WriteString(" 🔒")
+	}
+	if v.Description != "" {
+		sb.WriteString(color.HiBlackString("  # %s", v.Description))
+	}
 	sb.WriteString("\n")
 
 	// Final value
 	finalVal := v.FinalValue
-	if finalVal == "" {
+	if v.FinalFrom.Unresolvable {
+		finalVal = color.HiBlackString(unresolvableLabel)
+	} else if finalVal == "" {
 		finalVal = color.HiBlackString("(empty)")
 	}
 	sb.WriteString(fmt.Sprintf("  final: %s\n", finalVal))
 
 	// Source
 	src := v.FinalFrom
-	loc := src.File
-	if src.Line > 0 {
-		loc = fmt.Sprintf("%s:%d", src.File, src.Line)
-	}
 	if src.Service != "" {
 		sb.WriteString(fmt.Sprintf("  from: %s (service: %s)\n", src.Layer, src.Service))
 	} else {
-		sb.WriteString(fmt.Sprintf("  from: %s\n", loc))
+		sb.WriteString(fmt.Sprintf("  from: %s\n", src.Location()))
 	}
 
 	// Show override chain for overridden vars
@@ -98,92 +176,20 @@ func formatVariable(sb *strings.Builder, v *resolver.Variable, showChain bool) {
 				marker = "→ "
 			}
 
-			loc := s.Layer.String()
-			if s.File != "" {
-				loc = s.File
-				if s.Line > 0 {
-					loc = fmt.Sprintf("%s:%d", s.File, s.Line)
-				}
-			}
-
 			val := s.Value
-			if val == "" {
+			if s.Unresolvable {
+				val = unresolvableLabel
+			} else if val == "" {
 				val = "(empty)"
 			}
-			sb.WriteString(fmt.Sprintf("    %s%s = %s\n", marker, loc, val))
-		}
-	}
-
-	sb.WriteString("\n")
-}
-
-// FormatJSON generates JSON output
-func FormatJSON(r *resolver.Resolution) (string, error) {
-	type jsonSource struct {
-		Layer   string `json:"layer"`
-		File    string `json:"file,omitempty"`
-		Line    int    `json:"line,omitempty"`
-		Service string `json:"service,omitempty"`
-		Value   string `json:"value"`
-	}
-
-	type jsonVariable struct {
-		Name       string       `json:"name"`
-		FinalValue string       `json:"final_value"`
-		FinalFrom  jsonSource   `json:"final_from"`
-		Overridden bool         `json:"overridden"`
-		Chain      []jsonSource `json:"chain,omitempty"`
-	}
-
-	type jsonOutput struct {
-		Path         string         `json:"path"`
-		EnvFiles     []string       `json:"env_files"`
-		ComposeFiles []string       `json:"compose_files"`
-		Variables    []jsonVariable `json:"variables"`
-		Warnings     []string       `json:"warnings,omitempty"`
-	}
-
-	out := jsonOutput{
-		Path:         r.Path,
-		EnvFiles:     r.EnvFiles,
-		ComposeFiles: r.ComposeFiles,
-		Warnings:     r.Warnings,
-	}
-
-	for _, v := range r.Variables {
-		jv := jsonVariable{
-			Name:       v.Name,
-			FinalValue: v.FinalValue,
-			FinalFrom: jsonSource{
-				Layer:   v.FinalFrom.Layer.String(),
-				File:    v.FinalFrom.File,
-				Line:    v.FinalFrom.Line,
-				Service: v.FinalFrom.Service,
-				Value:   v.FinalFrom.Value,
-			},
-			Overridden: v.Overridden,
-		}
-
-		if v.Overridden {
-			for _, s := range v.Chain {
-				jv.Chain = append(jv.Chain, jsonSource{
-					Layer:   s.Layer.String(),
-					File:    s.File,
-					Line:    s.Line,
-					Service: s.Service,
-					Value:   s.Value,
-				})
+			if s.Encrypted && !s.Unresolvable {
+				val += color.HiBlackString(" (encrypted)")
 			}
+			sb.WriteString(fmt.Sprintf("    %s%s = %s\n", marker, s.Location(), val))
 		}
-
-		out.Variables = append(out.Variables, jv)
 	}
 
-	data, err := json.MarshalIndent(out, "", "  ")
-	if err != nil {
-		return "", err
-	}
-	return string(data), nil
+	sb.WriteString("\n")
 }
 
 // FormatMarkdown generates markdown output
@@ -220,10 +226,67 @@ func FormatMarkdown(r *resolver.Resolution) (string, error) {
 		sb.WriteString("\n")
 	}
 
-	// Variables table
+	// Keys repeated within one file
+	if len(r.Duplicates) > 0 {
+		sb.WriteString("## ⚠️ Duplicate Keys\n\n")
+		sb.WriteString("| Variable | File | Lines | Takes effect |\n")
+		sb.WriteString("|----------|------|-------|--------------|\n")
+		for _, d := range r.Duplicates {
+			sb.WriteString(fmt.Sprintf("| `%s` | `%s` | %s | line %d |\n",
+				d.Variable, d.File, joinLines(d.Lines), d.Effective))
+		}
+		sb.WriteString("\n")
+	}
+
+	// SOPS files
+	if len(r.EncryptedFiles) > 0 {
+		sb.WriteString("## 🔐 Encrypted Files\n\n")
+		sb.WriteString("| File | Format | Decrypted | Encrypted keys | Plaintext keys |\n")
+		sb.WriteString("|------|--------|-----------|----------------|----------------|\n")
+		for _, f := range r.EncryptedFiles {
+			decrypted := "no"
+			if f.Decrypted {
+				decrypted = "yes"
+			}
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n",
+				f.File, f.Format, decrypted, codeList(f.Encrypted), codeList(f.Plaintext)))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Schema violations
+	if len(r.Violations) > 0 {
+		sb.WriteString("## ❌ Schema Violations\n\n")
+		sb.WriteString("| Variable | Service | Rule | Problem | Set at |\n")
+		sb.WriteString("|----------|---------|------|---------|--------|\n")
+		for _, v := range r.Violations {
+			loc := ""
+			if v.Source.File != "" {
+				loc = "`" + v.Source.Location() + "`"
+			}
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n",
+				v.Variable, v.Service, v.Rule, v.Message, loc))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Variables table; the description column only appears when documented
+	withDesc := false
+	for _, v := range r.Variables {
+		if v.Description != "" {
+			withDesc = true
+			break
+		}
+	}
+
 	sb.WriteString("## Resolved Variables\n\n")
-	sb.WriteString("| Variable | Final Value | Source | Overridden |\n")
-	sb.WriteString("|----------|-------------|--------|------------|\n")
+	if withDesc {
+		sb.WriteString("| Variable | Final Value | Source | Overridden | Description |\n")
+		sb.WriteString("|----------|-------------|--------|------------|-------------|\n")
+	} else {
+		sb.WriteString("| Variable | Final Value | Source | Overridden |\n")
+		sb.WriteString("|----------|-------------|--------|------------|\n")
+	}
 
 	// Sort: overridden first
 	vars := make([]*resolver.Variable, len(r.Variables))
@@ -241,6 +304,9 @@ func FormatMarkdown(r *resolver.Resolution) (string, error) {
 			val = val[:27] + "..."
 		}
 		val = "`" + val + "`"
+		if v.FinalFrom.Unresolvable {
+			val = "_" + strings.Trim(unresolvableLabel, "()") + "_"
+		}
 
 		src := v.FinalFrom.Layer.String()
 		if v.FinalFrom.Service != "" {
@@ -252,7 +318,12 @@ func FormatMarkdown(r *resolver.Resolution) (string, error) {
 			override = "⚠️ Yes"
 		}
 
-		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", v.Name, val, src, override))
+		if withDesc {
+			desc := strings.ReplaceAll(v.Description, "|", "\\|")
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n", v.Name, val, src, override, desc))
+		} else {
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", v.Name, val, src, override))
+		}
 	}
 
 	return sb.String(), nil
@@ -0,0 +1,154 @@
+package reporter
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/lint"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolURI      = "https://github.com/stackgen-cli/envmerge"
+)
+
+// SARIF 2.1.0 subset used by envmerge
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string          `json:"id"`
+		ShortDescription     sarifMessage    `json:"shortDescription"`
+		DefaultConfiguration sarifRuleConfig `json:"defaultConfiguration"`
+	}
+	sarifRuleConfig struct {
+		Level string `json:"level"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           *sarifRegion  `json:"region,omitempty"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// sarifLevel maps a severity onto a SARIF result level
+func sarifLevel(s lint.Severity) string {
+	switch s {
+	case lint.SeverityError:
+		return "error"
+	case lint.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifURI renders a path as a relative URI with forward slashes, or a
+// file URI when absolute
+func sarifURI(path string) string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	if strings.HasPrefix(path, "/") {
+		return "file://" + path
+	}
+	return strings.TrimPrefix(path, "./")
+}
+
+// FormatSARIF renders diagnostics as a SARIF 2.1.0 log for code scanning.
+// rules supplies the metadata for every rule ID that may appear
+func FormatSARIF(rules []RuleInfo, diags []lint.Diagnostic, version string) (string, error) {
+	driver := sarifDriver{
+		Name:           "envmerge",
+		Version:        version,
+		InformationURI: toolURI,
+		Rules:          []sarifRule{},
+	}
+	index := make(map[string]int)
+	for _, r := range rules {
+		index[r.ID] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifRuleConfig{Level: sarifLevel(r.Severity)},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, d := range diags {
+		i, ok := index[d.RuleID]
+		if !ok {
+			// Keep the log self-consistent even for unknown rules
+			i = len(driver.Rules)
+			index[d.RuleID] = i
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:                   d.RuleID,
+				ShortDescription:     sarifMessage{Text: d.RuleID},
+				DefaultConfiguration: sarifRuleConfig{Level: sarifLevel(d.Severity)},
+			})
+		}
+
+		res := sarifResult{
+			RuleID:    d.RuleID,
+			RuleIndex: i,
+			Level:     sarifLevel(d.Severity),
+			Message:   sarifMessage{Text: d.Message},
+		}
+		if d.Location.File != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: sarifURI(d.Location.File)},
+			}}
+			if d.Location.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: d.Location.Line, StartColumn: d.Location.Column}
+			}
+			res.Locations = []sarifLocation{loc}
+		}
+		results = append(results, res)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,122 @@
+package reporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/lint"
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/schema"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+func TestFormatSARIF_ScanFindings(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=3000\n  STRIPE_KEY=sk_live_abcdefghijklmnop1234\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env.local"), []byte("PORT=4000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "compose.yml"), []byte("services:\n  api:\n    environment:\n      - MISSING\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	secrets.Mark(r)
+
+	out, err := FormatSARIF(ScanRules(), ScanDiagnostics(r), "1.2.3")
+	if err != nil {
+		t.Fatalf("FormatSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version = %s, runs = %d", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" || len(run.Tool.Driver.Rules) != len(ScanRules()) {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+
+	got := make(map[string]sarifResult)
+	for _, res := range run.Results {
+		if run.Tool.Driver.Rules[res.RuleIndex].ID != res.RuleID {
+			t.Errorf("ruleIndex %d does not point at %s", res.RuleIndex, res.RuleID)
+		}
+		got[res.RuleID] = res
+	}
+
+	override := got[RuleOverride]
+	if override.Level != "note" || len(override.Locations) != 1 {
+		t.Fatalf("override = %+v", override)
+	}
+	if loc := override.Locations[0].PhysicalLocation; filepath.Base(loc.ArtifactLocation.URI) != ".env.local" || loc.Region.StartLine != 1 {
+		t.Errorf("override location = %+v", loc)
+	}
+
+	secret := got[RulePlainSecret]
+	if len(secret.Locations) != 1 || secret.Locations[0].PhysicalLocation.Region.StartColumn != 3 {
+		t.Errorf("secret = %+v, want column 3", secret)
+	}
+	if got[RuleUndefined].Level != "error" {
+		t.Errorf("undefined = %+v", got[RuleUndefined])
+	}
+}
+
+// detectedSecret is a GitHub token in a variable the schema does not mark
+// @secret, so only secrets.Mark knows to hide it
+var detectedSecret = "ghp_" + strings.Repeat("a1B2", 9)
+
+// detectedSecretDiagnostics scans a project where detectedSecret fails a
+// schema type check
+func detectedSecretDiagnostics(t *testing.T) []lint.Diagnostic {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env.example"), []byte("# @type=int\nAPI_TOKEN=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("API_TOKEN="+detectedSecret+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	s, err := schema.Discover(dir, "")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	schema.Annotate(s, r)
+	secrets.Mark(r)
+	schema.Validate(s, r)
+
+	diags := ScanDiagnostics(r)
+	for _, d := range diags {
+		if d.RuleID == RuleSchema {
+			return diags
+		}
+	}
+	t.Fatalf("no %s diagnostic in %+v", RuleSchema, diags)
+	return nil
+}
+
+func TestFormatSARIF_HidesDetectedSecrets(t *testing.T) {
+	out, err := FormatSARIF(ScanRules(), detectedSecretDiagnostics(t), "1.2.3")
+	if err != nil {
+		t.Fatalf("FormatSARIF failed: %v", err)
+	}
+	if strings.Contains(out, detectedSecret) {
+		t.Errorf("SARIF output contains the secret value:\n%s", out)
+	}
+}
@@ -0,0 +1,94 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+	"github.com/stackgen-cli/envmerge/internal/shell"
+)
+
+// ParseTemplate parses a text/template that is executed with the
+// *resolver.Resolution as data. Besides the built-ins it provides:
+//
+//	redact       the masked value of a secret variable, or mask a string
+//	quote        a double-quoted string with Go/HCL escapes
+//	shellescape  a single-quoted string for POSIX shells
+//	json         any value as JSON
+//	toUpper      upper-case a string
+//	byService    the variables a compose service sets, with its values
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// TemplateFormatter renders resolutions through tmpl
+func TemplateFormatter(tmpl *template.Template) Formatter {
+	return func(w io.Writer, r *resolver.Resolution, _ Options) error {
+		funcs := template.FuncMap{
+			"byService": func(service string) ([]*resolver.Variable, error) { return byService(r, service) },
+		}
+		t, err := tmpl.Clone()
+		if err != nil {
+			return err
+		}
+		return t.Funcs(funcs).Execute(w, r)
+	}
+}
+
+var templateFuncs = template.FuncMap{
+	"redact":      redactValue,
+	"quote":       strconv.Quote,
+	"shellescape": shell.QuotePOSIX,
+	"toUpper":     strings.ToUpper,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// Replaced per resolution by TemplateFormatter
+	"byService": func(string) ([]*resolver.Variable, error) { return nil, nil },
+}
+
+// redactValue masks a variable's final value when it is secret, or masks a
+// string unconditionally
+func redactValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case *resolver.Variable:
+		if v.Secret {
+			return secrets.Mask(v.FinalValue), nil
+		}
+		return v.FinalValue, nil
+	case string:
+		return secrets.Mask(v), nil
+	default:
+		return "", fmt.Errorf("redact: want a variable or string, got %T", v)
+	}
+}
+
+// byService returns the variables service sets itself, as the csv, json
+// and html reports list them, each with the value and source that win for it
+func byService(r *resolver.Resolution, service string) ([]*resolver.Variable, error) {
+	known := false
+	for _, svc := range r.Services() {
+		known = known || svc == service
+	}
+	if !known {
+		return nil, fmt.Errorf("byService: no compose service %q in %s", service, r.Path)
+	}
+
+	var out []*resolver.Variable
+	for _, v := range r.Variables {
+		if !receives(v, service) {
+			continue
+		}
+		src, _ := v.ForService(service)
+		c := *v
+		c.FinalValue, c.FinalFrom = src.Value, src
+		out = append(out, &c)
+	}
+	return out, nil
+}
@@ -0,0 +1,55 @@
+package reporter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+)
+
+func TestTemplateFormatter_Helpers(t *testing.T) {
+	r := projectResolution(t)
+	secrets.Mark(r)
+
+	tmpl, err := ParseTemplate("tfvars", `{{range byService "api"}}{{toUpper .Name}} = {{quote (redact .)}}
+{{end}}{{range byService "worker"}}{{.Name}}={{.FinalValue}}
+{{end}}{{shellescape "it's"}} {{json (index .ByName "DB_HOST").Conflicts}} {{redact "x"}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := TemplateFormatter(tmpl)(&buf, r, Options{}); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	want := `DB_HOST = "db"
+FEATURE_FLAG = ""
+LOG_LEVEL = "debug"
+PORT=9000
+'it'\''s' ["localhost"] ` + secrets.Mask("x")
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestTemplateFormatter_RedactRejectsOtherTypes(t *testing.T) {
+	tmpl, err := ParseTemplate("bad", `{{redact 3}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := TemplateFormatter(tmpl)(&buf, projectResolution(t), Options{}); err == nil {
+		t.Error("redact of an int should fail")
+	}
+}
+
+func TestTemplateFormatter_ByServiceRejectsUnknownService(t *testing.T) {
+	tmpl, err := ParseTemplate("bad", `{{range byService "web"}}{{.Name}}{{end}}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := TemplateFormatter(tmpl)(&buf, projectResolution(t), Options{}); err == nil {
+		t.Error("byService of an unknown service should fail")
+	}
+}
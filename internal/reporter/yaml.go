@@ -0,0 +1,189 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatYAML generates YAML output with the same structure as FormatJSON
+func FormatYAML(r *resolver.Resolution) (string, error) {
+	node, err := reportNode(r)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	enc := yaml.NewEncoder(&sb)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// FormatTOML generates TOML output with the same structure as FormatJSON
+func FormatTOML(r *resolver.Resolution) (string, error) {
+	node, err := reportNode(r)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := writeTOMLTable(&sb, nil, node); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// reportNode parses the JSON report into a YAML node tree, which keeps the
+// field order of the output types. JSON is valid YAML
+func reportNode(r *resolver.Resolution) (*yaml.Node, error) {
+	data, err := json.Marshal(NewJSONReport(r))
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	node := doc.Content[0]
+	clearStyle(node)
+	return node, nil
+}
+
+// clearStyle drops the flow and quoting styles taken from the JSON source,
+// so values are written in block style and quoted only when needed
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
+
+// writeTOMLTable writes the keys of a mapping node: plain values first,
+// then sub-tables and arrays of tables, as TOML requires
+func writeTOMLTable(sb *strings.Builder, path []string, n *yaml.Node) error {
+	var tables, arrays []int
+	for i := 0; i < len(n.Content); i += 2 {
+		key, value := n.Content[i].Value, n.Content[i+1]
+		switch {
+		case value.Kind == yaml.MappingNode:
+			tables = append(tables, i)
+		case isTableArray(value):
+			arrays = append(arrays, i)
+		default:
+			v, err := tomlValue(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", strings.Join(append(path, key), "."), err)
+			}
+			sb.WriteString(tomlKey(key) + " = " + v + "\n")
+		}
+	}
+
+	for _, i := range tables {
+		sub := append(append([]string(nil), path...), n.Content[i].Value)
+		sb.WriteString("\n[" + tomlPath(sub) + "]\n")
+		if err := writeTOMLTable(sb, sub, n.Content[i+1]); err != nil {
+			return err
+		}
+	}
+	for _, i := range arrays {
+		sub := append(append([]string(nil), path...), n.Content[i].Value)
+		for _, item := range n.Content[i+1].Content {
+			sb.WriteString("\n[[" + tomlPath(sub) + "]]\n")
+			if err := writeTOMLTable(sb, sub, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isTableArray reports whether n is a non-empty list of mappings
+func isTableArray(n *yaml.Node) bool {
+	if n.Kind != yaml.SequenceNode || len(n.Content) == 0 {
+		return false
+	}
+	for _, c := range n.Content {
+		if c.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlValue renders a scalar or an inline array
+func tomlValue(n *yaml.Node) (string, error) {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := tomlValue(c)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, v)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case yaml.ScalarNode:
+		switch n.Tag {
+		case "!!str":
+			return tomlString(n.Value), nil
+		case "!!int", "!!bool", "!!float":
+			return n.Value, nil
+		}
+	}
+	return "", fmt.Errorf("no TOML representation for %s", n.Tag)
+}
+
+// tomlString quotes s as a TOML basic string
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range s {
+		switch {
+		case c == '"':
+			sb.WriteString(`\"`)
+		case c == '\\':
+			sb.WriteString(`\\`)
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\t':
+			sb.WriteString(`\t`)
+		case c == '\r':
+			sb.WriteString(`\r`)
+		case c < 0x20 || c == 0x7f:
+			sb.WriteString(fmt.Sprintf(`\u%04X`, c))
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// tomlKey leaves bare keys alone and quotes the rest
+func tomlKey(k string) string {
+	for _, c := range k {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return tomlString(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, k := range path {
+		keys[i] = tomlKey(k)
+	}
+	return strings.Join(keys, ".")
+}
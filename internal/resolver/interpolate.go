@@ -0,0 +1,81 @@
+package resolver
+
+import "regexp"
+
+// Expansion is one $VAR or ${VAR} reference substituted by Interpolate
+type Expansion struct {
+	Reference string // As written, e.g. ${PORT:-5432}
+	Name      string
+	Value     string
+	From      *Source // Where the referenced value came from; nil for defaults
+	Default   bool    // The inline default was used
+	Missing   bool    // Undefined, with no default
+}
+
+// refRe matches $$, ${NAME}, ${NAME:-default}, ${NAME-default} and $NAME
+var refRe = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Interpolate substitutes references in value with the resolved values of
+// other variables, as seen by service when one is given, the way compose
+// and dotenv-expand do. It returns each substitution and the result
+func (r *Resolution) Interpolate(value, service string) ([]Expansion, string) {
+	return interpolate(value, func(name string) (Source, bool) {
+		return r.Lookup(name, service)
+	})
+}
+
+// InterpolateCompose substitutes references in an inline compose value.
+// Compose reads them from the project .env files and the shell only, never
+// from a service's env_file or from .env.example
+func (r *Resolution) InterpolateCompose(value string) ([]Expansion, string) {
+	return interpolate(value, r.lookupProject)
+}
+
+// lookupProject is Lookup restricted to the project .env files and the shell
+func (r *Resolution) lookupProject(name string) (Source, bool) {
+	v, ok := r.ByName[name]
+	if !ok {
+		return Source{}, false
+	}
+	for i := len(v.Chain) - 1; i >= 0; i-- {
+		src := v.Chain[i]
+		if src.Service != "" || src.Layer == LayerEnvExample {
+			continue
+		}
+		if src.Unresolvable {
+			return Source{}, false
+		}
+		return src, true
+	}
+	return Source{}, false
+}
+
+func interpolate(value string, lookup func(name string) (Source, bool)) ([]Expansion, string) {
+	var steps []Expansion
+	expanded := refRe.ReplaceAllStringFunc(value, func(ref string) string {
+		if ref == "$$" {
+			return "$"
+		}
+		m := refRe.FindStringSubmatch(ref)
+		name, op, def := m[1], m[2], m[3]
+		if name == "" {
+			name = m[4]
+		}
+
+		step := Expansion{Reference: ref, Name: name}
+		src, found := lookup(name)
+		switch {
+		case found && !(op == ":-" && src.Value == ""):
+			step.Value = src.Value
+			step.From = &src
+		case op != "":
+			step.Value = def
+			step.Default = true
+		default:
+			step.Missing = true
+		}
+		steps = append(steps, step)
+		return step.Value
+	})
+	return steps, expanded
+}
@@ -0,0 +1,62 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolate_DefaultsAndMissing(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_HOST=localhost\nEMPTY=\n"), 0644)
+	r, err := Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	steps, expanded := r.Interpolate("${DB_USER:-app}@${DB_HOST}:$DB_PORT/${EMPTY:-x}${EMPTY-y}$$", "")
+	if expanded != "app@localhost:/x$" {
+		t.Errorf("expanded = %s, want app@localhost:/x$", expanded)
+	}
+	if len(steps) != 5 {
+		t.Fatalf("got %d steps, want 5: %+v", len(steps), steps)
+	}
+	if !steps[0].Default || steps[1].From == nil || !steps[2].Missing || !steps[3].Default || steps[4].Default {
+		t.Errorf("steps = %+v", steps)
+	}
+}
+
+func TestInterpolateCompose_IgnoresEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":         "API_HOST=http://from-dotenv\n",
+		"api.env":      "API_HOST=http://from-env-file\n",
+		".env.example": "EXAMPLE_ONLY=placeholder\n",
+		"docker-compose.yml": `services:
+  api:
+    env_file: api.env
+    environment:
+      API_URL: ${API_HOST}/v1
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if _, expanded := r.InterpolateCompose("${API_HOST}/v1"); expanded != "http://from-dotenv/v1" {
+		t.Errorf("expanded = %s, want http://from-dotenv/v1", expanded)
+	}
+	if _, expanded := r.InterpolateCompose("${EXAMPLE_ONLY:-unset}"); expanded != "unset" {
+		t.Errorf("expanded = %s, want unset", expanded)
+	}
+	// Values read by the service itself still see its env_file
+	if _, expanded := r.Interpolate("${API_HOST}", "api"); expanded != "http://from-env-file" {
+		t.Errorf("expanded = %s, want http://from-env-file", expanded)
+	}
+}
@@ -2,14 +2,19 @@
 package resolver
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+	"github.com/stackgen-cli/envmerge/internal/dotenvx"
+	"github.com/stackgen-cli/envmerge/internal/sops"
 )
 
 // Layer represents the source layer of an environment variable
@@ -70,12 +75,39 @@ func (l Layer) Precedence() int {
 
 // Source represents where a variable value came from
 type Source struct {
-	Layer    Layer
-	File     string
-	Line     int
-	Service  string // For compose sources
-	Value    string
-	IsInline bool
+	Layer     Layer
+	File      string
+	Line      int
+	Service   string // For compose sources
+	Value     string
+	IsInline  bool
+	Encrypted bool // Stored encrypted (SOPS or dotenvx)
+	Column    int  // 1-based column of the key, when known
+
+	// Unresolvable marks an encrypted value that could not be decrypted;
+	// Value is empty rather than the ciphertext
+	Unresolvable bool
+}
+
+// Location renders file:line, the file alone, or the layer for sources
+// without a file and for the OS environment
+func (s Source) Location() string {
+	switch {
+	case s.File == "" || s.Layer == LayerOSEnv:
+		return s.Layer.String()
+	case s.Line > 0:
+		return fmt.Sprintf("%s:%d", s.File, s.Line)
+	default:
+		return s.File
+	}
+}
+
+// Label is Location followed by the compose service, if any
+func (s Source) Label() string {
+	if s.Service != "" {
+		return fmt.Sprintf("%s (service: %s)", s.Location(), s.Service)
+	}
+	return s.Location()
 }
 
 // Variable represents a resolved environment variable
@@ -86,6 +118,9 @@ type Variable struct {
 	Chain      []Source // All sources in precedence order
 	Overridden bool
 	Conflicts  []string // Different values from different sources
+
+	Description string // From schema or .env.example annotations
+	Secret      bool   // Declared sensitive by the schema
 }
 
 // Resolution is the complete resolution result
@@ -97,14 +132,54 @@ type Resolution struct {
 	ComposeFiles []string
 	Warnings     []string
 	Undefined    []string // Variables referenced but not defined anywhere
+	Violations   []Violation
+	Duplicates   []Duplicate // Keys repeated within a single file
+
+	EncryptedFiles []EncryptedFile // SOPS files, and env files holding dotenvx values
+
+	keyring     *sops.Keyring
+	dotenvxKeys *dotenvx.Keyring
+}
+
+// Violation is a schema check failure tied to the source that set the value
+type Violation struct {
+	Variable string
+	Service  string // Empty when the check applies project-wide
+	Rule     string // e.g. "required", "type", "enum", "pattern", "min", "max"
+	Message  string
+	Source   Source // Where the offending value came from
+}
+
+// Duplicate records a key defined more than once in the same file
+type Duplicate struct {
+	Variable  string
+	File      string
+	Lines     []int // Every line defining the key, in file order
+	Effective int   // The line whose value is used: the last one
+}
+
+// EncryptedFile summarizes an encrypted env file read during resolution
+type EncryptedFile struct {
+	File      string
+	Format    string   // SOPS dotenv, yaml or json, or dotenvx
+	Encrypted []string // Keys stored encrypted
+	Plaintext []string // Keys SOPS left in the clear
+	Decrypted bool     // False when no key could decrypt the values
+}
+
+// IsSOPS reports whether the whole file was written by SOPS, as opposed to
+// a hand-edited .env file holding some dotenvx values
+func (f EncryptedFile) IsSOPS() bool {
+	return f.Format != "dotenvx"
 }
 
 // Options for resolution
 type Options struct {
-	IncludeOSEnv bool     // Include system environment variables
-	ServiceName  string   // Filter to specific service
-	StrictMode   bool     // Return error if undefined vars found
-	CompareWith  string   // Path to compare environments
+	IncludeOSEnv bool   // Include system environment variables
+	ServiceName  string // Filter to specific service
+	StrictMode   bool   // Return error if undefined vars found
+	CompareWith  string // Path to compare environments
+	AgeKeyFile   string // age identity for SOPS files (default: $SOPS_AGE_KEY_FILE)
 }
 
 // Resolve scans and resolves all environment variables
@@ -118,6 +193,9 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 		Path:     basePath,
 		ByName:   make(map[string]*Variable),
 		Warnings: []string{},
+		keyring:  sops.NewKeyring(opts.AgeKeyFile),
+
+		dotenvxKeys: dotenvx.NewKeyring(),
 	}
 
 	// 1. Find and parse .env files (in precedence order)
@@ -134,7 +212,7 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 		envPath := filepath.Join(basePath, ep.pattern)
 		if _, err := os.Stat(envPath); err == nil {
 			r.EnvFiles = append(r.EnvFiles, envPath)
-			if err := r.parseEnvFile(envPath, ep.layer); err != nil {
+			if err := r.parseEnvFile(envPath, ep.layer, ""); err != nil {
 				r.Warnings = append(r.Warnings, fmt.Sprintf("Error parsing %s: %v", ep.pattern, err))
 			}
 		}
@@ -146,10 +224,11 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 		name := entry.Name()
 		if strings.HasPrefix(name, ".env.") &&
 			name != ".env.example" &&
-			name != ".env.local" {
+			name != ".env.local" &&
+			!isNonEnvFile(name) {
 			envPath := filepath.Join(basePath, name)
 			r.EnvFiles = append(r.EnvFiles, envPath)
-			if err := r.parseEnvFile(envPath, LayerEnvOther); err != nil {
+			if err := r.parseEnvFile(envPath, LayerEnvOther, ""); err != nil {
 				r.Warnings = append(r.Warnings, fmt.Sprintf("Error parsing %s: %v", name, err))
 			}
 		}
@@ -176,7 +255,9 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 	// 3. Build the variables list sorted by name
 	for _, v := range r.ByName {
 		// Sort chain by precedence
-		sort.Slice(v.Chain, func(i, j int) bool {
+		// Stable so that, within a layer, later definitions stay later and
+		// win, matching how dotenv loaders treat repeated keys
+		sort.SliceStable(v.Chain, func(i, j int) bool {
 			return v.Chain[i].Layer.Precedence() < v.Chain[j].Layer.Precedence()
 		})
 
@@ -186,6 +267,11 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 			v.FinalValue = v.FinalFrom.Value
 		}
 
+		// Decrypted values are secret whatever they look like
+		if v.IsEncrypted() {
+			v.Secret = true
+		}
+
 		// Check for conflicts (different values)
 		values := make(map[string]bool)
 		for _, src := range v.Chain {
@@ -200,6 +286,7 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 					v.Conflicts = append(v.Conflicts, val)
 				}
 			}
+			sort.Strings(v.Conflicts)
 		}
 
 		r.Variables = append(r.Variables, v)
@@ -210,6 +297,8 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 		return r.Variables[i].Name < r.Variables[j].Name
 	})
 
+	r.findDuplicates()
+
 	// Add OS environment variables if requested
 	if opts.IncludeOSEnv {
 		for _, env := range os.Environ() {
@@ -243,7 +332,7 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 	if opts.StrictMode {
 		r.findUndefinedVars()
 		if len(r.Undefined) > 0 {
-			return r, fmt.Errorf("strict mode: %d undefined variable(s): %s", 
+			return r, fmt.Errorf("strict mode: %d undefined variable(s): %s",
 				len(r.Undefined), strings.Join(r.Undefined, ", "))
 		}
 	}
@@ -251,6 +340,89 @@ func ResolveWithOptions(basePath string, opts Options) (*Resolution, error) {
 	return r, nil
 }
 
+// nonEnvFiles are .env.* names that hold envmerge metadata rather than variables
+var nonEnvFiles = []string{".env.schema", ".env.schema.json", dotenvx.KeysFile}
+
+func isNonEnvFile(name string) bool {
+	for _, n := range nonEnvFiles {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEnvFileName reports whether a file name looks like a dotenv file: .env,
+// .env.* or *.env, excluding envmerge's own metadata files
+func IsEnvFileName(name string) bool {
+	if isNonEnvFile(name) {
+		return false
+	}
+	return name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env")
+}
+
+// IsEncrypted reports whether any candidate value was decrypted from an
+// encrypted file
+func (v *Variable) IsEncrypted() bool {
+	for _, src := range v.Chain {
+		if src.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+// Services returns the names of all compose services that contribute a source
+func (r *Resolution) Services() []string {
+	seen := make(map[string]bool)
+	for _, v := range r.ByName {
+		for _, src := range v.Chain {
+			if src.Service != "" {
+				seen[src.Service] = true
+			}
+		}
+	}
+
+	var names []string
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForService returns the winning source for a variable as seen by one
+// service: project-wide sources plus that service's own compose sources
+func (v *Variable) ForService(service string) (Source, bool) {
+	for i := len(v.Chain) - 1; i >= 0; i-- {
+		src := v.Chain[i]
+		if src.Service == "" || src.Service == service {
+			return src, true
+		}
+	}
+	return Source{}, false
+}
+
+// Lookup returns the winning source for name, as seen by service when one
+// is given. Unresolvable values and bare compose references (a name with no
+// value) do not count as defined
+func (r *Resolution) Lookup(name, service string) (Source, bool) {
+	v, ok := r.ByName[name]
+	if !ok || len(v.Chain) == 0 {
+		return Source{}, false
+	}
+	src := v.FinalFrom
+	if service != "" {
+		if src, ok = v.ForService(service); !ok {
+			return Source{}, false
+		}
+	}
+	if src.Unresolvable || (src.IsInline && src.Value == "") {
+		return Source{}, false
+	}
+	return src, true
+}
+
 // filterToService filters variables to only those used by a specific service
 func (r *Resolution) filterToService(serviceName string) {
 	var filtered []*Variable
@@ -273,72 +445,204 @@ func (r *Resolution) filterToService(serviceName string) {
 	r.Variables = filtered
 }
 
+// findDuplicates records keys that a single file defines more than once.
+// An env_file shared by several services is reported once
+func (r *Resolution) findDuplicates() {
+	for _, v := range r.Variables {
+		lines := make(map[string][]int)
+		var files []string
+		for _, src := range v.Chain {
+			if src.Line == 0 {
+				continue
+			}
+			seen := lines[src.File]
+			if len(seen) == 0 {
+				files = append(files, src.File)
+			}
+			if !containsInt(seen, src.Line) {
+				lines[src.File] = append(seen, src.Line)
+			}
+		}
+
+		for _, file := range files {
+			if len(lines[file]) < 2 {
+				continue
+			}
+			sort.Ints(lines[file])
+			r.Duplicates = append(r.Duplicates, Duplicate{
+				Variable:  v.Name,
+				File:      file,
+				Lines:     lines[file],
+				Effective: lines[file][len(lines[file])-1],
+			})
+		}
+	}
+}
+
+func containsInt(list []int, n int) bool {
+	for _, item := range list {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
+
 // findUndefinedVars looks for variables referenced but not defined
 func (r *Resolution) findUndefinedVars() {
-	// Check for ${VAR} references in compose that have empty final values
+	r.Undefined = r.UndefinedVars()
+}
+
+// UndefinedVars returns the variables that appear somewhere (such as a
+// ${VAR} reference in compose) but are never given a value. Encrypted
+// values that could not be decrypted count as defined
+func (r *Resolution) UndefinedVars() []string {
+	var undefined []string
 	for _, v := range r.Variables {
-		if v.FinalValue == "" {
-			// Check if this is only a reference (no definition found)
-			hasDefinition := false
-			for _, src := range v.Chain {
-				if src.Value != "" {
-					hasDefinition = true
-					break
-				}
-			}
-			if !hasDefinition {
-				r.Undefined = append(r.Undefined, v.Name)
+		if v.FinalValue != "" {
+			continue
+		}
+		hasDefinition := false
+		for _, src := range v.Chain {
+			if src.Value != "" || src.Unresolvable {
+				hasDefinition = true
+				break
 			}
 		}
+		if !hasDefinition {
+			undefined = append(undefined, v.Name)
+		}
 	}
+	return undefined
 }
 
-func (r *Resolution) parseEnvFile(path string, layer Layer) error {
-	file, err := os.Open(path)
+func (r *Resolution) parseEnvFile(path string, layer Layer, service string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if _, ok := sops.IsEncrypted(data); ok {
+		return r.parseSopsFile(path, data, layer, service)
+	}
 
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
+	doc, err := dotenv.Parse(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	enc := EncryptedFile{File: path, Format: "dotenvx", Decrypted: true}
+	var decryptErr error
 
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
+	for _, l := range doc.Lines {
+		// Comments, blank lines and lines without KEY= are skipped
+		if l.Kind != dotenv.LineEntry {
+			continue
+		}
+		key, value := l.Key, l.Value
 
-		// Skip comments and empty lines
-		if line == "" || strings.HasPrefix(line, "#") {
+		// DOTENV_PUBLIC_KEY headers describe the file, not the app
+		if dotenvx.IsPublicKey(key) {
 			continue
 		}
 
-		// Handle export prefix
-		line = strings.TrimPrefix(line, "export ")
-		line = strings.TrimSpace(line)
+		src := Source{
+			Layer:   layer,
+			File:    path,
+			Line:    l.Number,
+			Column:  l.KeyColumn(),
+			Service: service,
+			Value:   value,
+		}
+		if dotenvx.IsEncrypted(value) {
+			enc.Encrypted = append(enc.Encrypted, key)
+			src.Encrypted = true
+			if src.Value, err = r.decryptDotenvx(path, value); err != nil {
+				// Never let the ciphertext pose as the value
+				src.Value = ""
+				src.Unresolvable = true
+				enc.Decrypted = false
+				decryptErr = err
+			}
+		} else {
+			enc.Plaintext = append(enc.Plaintext, key)
+		}
+		r.addSource(key, src)
+	}
 
-		// Parse KEY=VALUE
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
+	if len(enc.Encrypted) > 0 {
+		if r.addEncryptedFile(enc) && decryptErr != nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("Cannot decrypt values in %s: %v", filepath.Base(path), decryptErr))
 		}
+	}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		value = unquote(value)
+	return nil
+}
 
-		if key == "" {
-			continue
+// decryptDotenvx opens an "encrypted:" value with the private key for path
+func (r *Resolution) decryptDotenvx(path, value string) (string, error) {
+	key, ok := r.dotenvxKeys.PrivateKey(path)
+	if !ok {
+		return "", fmt.Errorf("%w (set %s%s in %s or the environment)",
+			dotenvx.ErrNoKey, dotenvx.PrivateKeyName, dotenvx.KeySuffix(path), dotenvx.KeysFile)
+	}
+	return dotenvx.Decrypt(value, key)
+}
+
+// addEncryptedFile records f once, even if several services load it, and
+// reports whether it was new
+func (r *Resolution) addEncryptedFile(f EncryptedFile) bool {
+	for _, existing := range r.EncryptedFiles {
+		if existing.File == f.File {
+			return false
 		}
+	}
+	r.EncryptedFiles = append(r.EncryptedFiles, f)
+	return true
+}
 
-		r.addSource(key, Source{
-			Layer: layer,
-			File:  path,
-			Line:  lineNum,
-			Value: value,
-		})
+// parseSopsFile decrypts a SOPS file with the configured age identity.
+// Without one, encrypted keys are recorded as unresolvable
+func (r *Resolution) parseSopsFile(path string, data []byte, layer Layer, service string) error {
+	f, err := sops.Parse(path, data)
+	if err != nil {
+		return err
 	}
 
-	return scanner.Err()
+	var decryptErr error
+	if len(f.EncryptedKeys()) > 0 {
+		ids, err := r.keyring.Identities()
+		if err == nil {
+			err = f.Decrypt(ids)
+		}
+		decryptErr = err
+	}
+
+	added := r.addEncryptedFile(EncryptedFile{
+		File:      path,
+		Format:    f.Format,
+		Encrypted: f.EncryptedKeys(),
+		Plaintext: f.PlaintextKeys(),
+		Decrypted: f.Decrypted,
+	})
+	if added && decryptErr != nil {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("Cannot decrypt %s: %v", filepath.Base(path), decryptErr))
+	}
+
+	for _, v := range f.Values {
+		src := Source{
+			Layer:     layer,
+			File:      path,
+			Line:      v.Line,
+			Service:   service,
+			Value:     v.Value,
+			Encrypted: v.Encrypted,
+		}
+		if v.Encrypted && !f.Decrypted {
+			src.Value = ""
+			src.Unresolvable = true
+		}
+		r.addSource(v.Key, src)
+	}
+	return nil
 }
 
 type composeFile struct {
@@ -359,7 +663,16 @@ func (r *Resolution) parseComposeFile(path string) error {
 		return err
 	}
 
-	for serviceName, svc := range compose.Services {
+	// Visit services in a fixed order so sources land in the chain deterministically
+	serviceNames := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		svc := compose.Services[serviceName]
+
 		// Parse env_file references
 		if svc.EnvFile != nil {
 			r.parseEnvFileRef(path, serviceName, svc.EnvFile)
@@ -393,36 +706,8 @@ func (r *Resolution) parseEnvFileRef(composePath, serviceName string, envFile in
 		envPath := filepath.Join(baseDir, f)
 		if _, err := os.Stat(envPath); err == nil {
 			// Parse this env file as compose env_file layer
-			file, err := os.Open(envPath)
-			if err != nil {
-				continue
-			}
-			defer file.Close()
-
-			scanner := bufio.NewScanner(file)
-			lineNum := 0
-			for scanner.Scan() {
-				lineNum++
-				line := strings.TrimSpace(scanner.Text())
-				if line == "" || strings.HasPrefix(line, "#") {
-					continue
-				}
-
-				parts := strings.SplitN(line, "=", 2)
-				if len(parts) != 2 {
-					continue
-				}
-
-				key := strings.TrimSpace(parts[0])
-				value := unquote(strings.TrimSpace(parts[1]))
-
-				r.addSource(key, Source{
-					Layer:   LayerComposeEnvFile,
-					File:    envPath,
-					Line:    lineNum,
-					Service: serviceName,
-					Value:   value,
-				})
+			if err := r.parseEnvFile(envPath, LayerComposeEnvFile, serviceName); err != nil {
+				r.Warnings = append(r.Warnings, fmt.Sprintf("Error parsing %s: %v", f, err))
 			}
 		}
 	}
@@ -431,7 +716,14 @@ func (r *Resolution) parseEnvFileRef(composePath, serviceName string, envFile in
 func (r *Resolution) parseInlineEnv(composePath, serviceName string, env interface{}) {
 	switch v := env.(type) {
 	case map[string]interface{}:
-		for key, val := range v {
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			val := v[key]
 			value := ""
 			if val != nil {
 				value = fmt.Sprintf("%v", val)
@@ -475,45 +767,64 @@ func (r *Resolution) addSource(name string, src Source) {
 	v.Chain = append(v.Chain, src)
 }
 
-func unquote(s string) string {
-	if len(s) >= 2 {
-		if (s[0] == '"' && s[len(s)-1] == '"') ||
-			(s[0] == '\'' && s[len(s)-1] == '\'') {
-			return s[1 : len(s)-1]
-		}
-	}
-	return s
-}
-
 // CompareResult holds the result of comparing two environment contexts
 type CompareResult struct {
 	OnlyInFirst  []string
 	OnlyInSecond []string
 	Different    []DiffVar
 	Same         []string
+	Ignored      []string // Names matching an ignore pattern, on either side
+
+	// Where each side's final value came from, by name
+	FirstFrom  map[string]Source
+	SecondFrom map[string]Source
 }
 
 // DiffVar represents a variable with different values
 type DiffVar struct {
-	Name       string
-	FirstValue string
+	Name        string
+	FirstValue  string
 	SecondValue string
+	FirstFrom   Source
+	SecondFrom  Source
+}
+
+// Missing counts the variables defined on only one side
+func (c *CompareResult) Missing() int {
+	return len(c.OnlyInFirst) + len(c.OnlyInSecond)
 }
 
 // Compare compares two resolutions and returns the differences
 func Compare(first, second *Resolution) *CompareResult {
-	result := &CompareResult{}
-
-	firstVars := make(map[string]string)
-	secondVars := make(map[string]string)
-
-	for _, v := range first.Variables {
-		firstVars[v.Name] = v.FinalValue
-	}
+	return CompareIgnoring(first, second, nil)
+}
 
-	for _, v := range second.Variables {
-		secondVars[v.Name] = v.FinalValue
+// CompareIgnoring is Compare, leaving out variables whose name matches
+// one of the glob patterns (as in path.Match, e.g. *_URL)
+func CompareIgnoring(first, second *Resolution, ignore []string) *CompareResult {
+	result := &CompareResult{
+		FirstFrom:  make(map[string]Source),
+		SecondFrom: make(map[string]Source),
+	}
+
+	ignored := make(map[string]bool)
+	collect := func(r *Resolution, from map[string]Source) map[string]string {
+		vars := make(map[string]string)
+		for _, v := range r.Variables {
+			if matchAny(ignore, v.Name) {
+				if !ignored[v.Name] {
+					ignored[v.Name] = true
+					result.Ignored = append(result.Ignored, v.Name)
+				}
+				continue
+			}
+			vars[v.Name] = v.FinalValue
+			from[v.Name] = v.FinalFrom
+		}
+		return vars
 	}
+	firstVars := collect(first, result.FirstFrom)
+	secondVars := collect(second, result.SecondFrom)
 
 	// Find vars only in first
 	for name := range firstVars {
@@ -537,6 +848,8 @@ func Compare(first, second *Resolution) *CompareResult {
 					Name:        name,
 					FirstValue:  firstVal,
 					SecondValue: secondVal,
+					FirstFrom:   result.FirstFrom[name],
+					SecondFrom:  result.SecondFrom[name],
 				})
 			} else {
 				result.Same = append(result.Same, name)
@@ -548,6 +861,7 @@ func Compare(first, second *Resolution) *CompareResult {
 	sort.Strings(result.OnlyInFirst)
 	sort.Strings(result.OnlyInSecond)
 	sort.Strings(result.Same)
+	sort.Strings(result.Ignored)
 	sort.Slice(result.Different, func(i, j int) bool {
 		return result.Different[i].Name < result.Different[j].Name
 	})
@@ -555,6 +869,27 @@ func Compare(first, second *Resolution) *CompareResult {
 	return result
 }
 
+// CheckPatterns returns an error for the first malformed glob pattern, as
+// matched by CompareIgnoring
+func CheckPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether name matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // FormatCompare formats a compare result as human-readable text
 func FormatCompare(first, second string, result *CompareResult) string {
 	var sb strings.Builder
@@ -564,7 +899,7 @@ func FormatCompare(first, second string, result *CompareResult) string {
 	if len(result.OnlyInFirst) > 0 {
 		sb.WriteString(fmt.Sprintf("## Only in %s (%d)\n", first, len(result.OnlyInFirst)))
 		for _, name := range result.OnlyInFirst {
-			sb.WriteString(fmt.Sprintf("  - %s\n", name))
+			sb.WriteString(fmt.Sprintf("  - %s (%s)\n", name, result.FirstFrom[name].Location()))
 		}
 		sb.WriteString("\n")
 	}
@@ -572,7 +907,7 @@ func FormatCompare(first, second string, result *CompareResult) string {
 	if len(result.OnlyInSecond) > 0 {
 		sb.WriteString(fmt.Sprintf("## Only in %s (%d)\n", second, len(result.OnlyInSecond)))
 		for _, name := range result.OnlyInSecond {
-			sb.WriteString(fmt.Sprintf("  - %s\n", name))
+			sb.WriteString(fmt.Sprintf("  - %s (%s)\n", name, result.SecondFrom[name].Location()))
 		}
 		sb.WriteString("\n")
 	}
@@ -581,8 +916,8 @@ func FormatCompare(first, second string, result *CompareResult) string {
 		sb.WriteString(fmt.Sprintf("## Different Values (%d)\n", len(result.Different)))
 		for _, diff := range result.Different {
 			sb.WriteString(fmt.Sprintf("  - %s:\n", diff.Name))
-			sb.WriteString(fmt.Sprintf("      %s: %s\n", first, diff.FirstValue))
-			sb.WriteString(fmt.Sprintf("      %s: %s\n", second, diff.SecondValue))
+			sb.WriteString(fmt.Sprintf("      %s: %s (%s)\n", first, diff.FirstValue, diff.FirstFrom.Location()))
+			sb.WriteString(fmt.Sprintf("      %s: %s (%s)\n", second, diff.SecondValue, diff.SecondFrom.Location()))
 		}
 		sb.WriteString("\n")
 	}
@@ -592,6 +927,9 @@ func FormatCompare(first, second string, result *CompareResult) string {
 	sb.WriteString(fmt.Sprintf("  - %d variable(s) only in %s\n", len(result.OnlyInSecond), second))
 	sb.WriteString(fmt.Sprintf("  - %d variable(s) with different values\n", len(result.Different)))
 	sb.WriteString(fmt.Sprintf("  - %d variable(s) with same values\n", len(result.Same)))
+	if len(result.Ignored) > 0 {
+		sb.WriteString(fmt.Sprintf("  - %d variable(s) ignored\n", len(result.Ignored)))
+	}
 
 	return sb.String()
 }
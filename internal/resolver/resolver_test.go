@@ -3,6 +3,7 @@ package resolver
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -595,3 +596,197 @@ ARRAY=[1, 2, 3, "test"]
 		t.Error("CONFIG value empty")
 	}
 }
+
+func TestResolve_IgnoresSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=3000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env.schema"), []byte("PORT type=port\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if len(result.EnvFiles) != 1 {
+		t.Errorf("EnvFiles = %v, want only .env", result.EnvFiles)
+	}
+}
+
+func TestVariable_ForService(t *testing.T) {
+	v := &Variable{
+		Name: "PORT",
+		Chain: []Source{
+			{Layer: LayerEnv, Value: "3000"},
+			{Layer: LayerComposeInline, Service: "api", Value: "8080"},
+			{Layer: LayerComposeInline, Service: "web", Value: "80"},
+		},
+	}
+
+	tests := map[string]string{"api": "8080", "web": "80", "worker": "3000"}
+	for svc, want := range tests {
+		src, ok := v.ForService(svc)
+		if !ok || src.Value != want {
+			t.Errorf("ForService(%s) = %q, want %q", svc, src.Value, want)
+		}
+	}
+}
+
+func TestResolution_Lookup(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("PORT=3000\nEMPTY=\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "compose.yml"), []byte(`services:
+  api:
+    environment:
+      PORT: "8080"
+      TOKEN:
+`), 0644)
+
+	r, err := Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	tests := []struct {
+		name, service, want string
+		ok                  bool
+	}{
+		{"PORT", "", "8080", true},
+		{"PORT", "worker", "3000", true},
+		{"EMPTY", "", "", true},
+		{"TOKEN", "api", "", false}, // Referenced by compose, never given a value
+		{"MISSING", "", "", false},
+	}
+	for _, tt := range tests {
+		src, ok := r.Lookup(tt.name, tt.service)
+		if ok != tt.ok || src.Value != tt.want {
+			t.Errorf("Lookup(%s, %q) = %q, %v, want %q, %v", tt.name, tt.service, src.Value, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolve_DuplicateKeyLastWins(t *testing.T) {
+	dir := t.TempDir()
+
+	envContent := `API_URL=http://first
+OTHER=x
+API_URL=http://second
+API_URL=http://third
+`
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(envContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Repeat often: an unstable sort would pick different winners
+	for i := 0; i < 20; i++ {
+		result, err := Resolve(dir)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+
+		v := result.ByName["API_URL"]
+		if v.FinalValue != "http://third" || v.FinalFrom.Line != 4 {
+			t.Fatalf("API_URL = %s (line %d), want http://third (line 4)", v.FinalValue, v.FinalFrom.Line)
+		}
+
+		if len(result.Duplicates) != 1 {
+			t.Fatalf("Duplicates = %+v, want 1 entry", result.Duplicates)
+		}
+		d := result.Duplicates[0]
+		if d.Variable != "API_URL" || d.Effective != 4 || len(d.Lines) != 3 || d.Lines[0] != 1 {
+			t.Errorf("Duplicate = %+v, want API_URL lines [1 3 4] effective 4", d)
+		}
+	}
+}
+
+func TestResolve_SharedEnvFileNotDuplicate(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "common.env"), []byte("SHARED=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	composeContent := `services:
+  api:
+    env_file: common.env
+  worker:
+    env_file: common.env
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(composeContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	if len(result.Duplicates) != 0 {
+		t.Errorf("Duplicates = %+v, want none", result.Duplicates)
+	}
+}
+
+func TestResolve_MultilineQuotedValue(t *testing.T) {
+	dir := t.TempDir()
+	content := "CERT=\"-----BEGIN-----\nabc\n-----END-----\"\nAFTER=1\n"
+	os.WriteFile(filepath.Join(dir, ".env"), []byte(content), 0644)
+
+	result, err := Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if got := result.ByName["CERT"].FinalValue; got != "-----BEGIN-----\nabc\n-----END-----" {
+		t.Errorf("CERT = %q", got)
+	}
+	if v := result.ByName["AFTER"]; v == nil || v.FinalFrom.Line != 4 {
+		t.Errorf("AFTER = %+v, want line 4", v)
+	}
+}
+
+func TestCompareIgnoring_SkipsPatternsAndKeepsSources(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(first, ".env"), []byte("PORT=3000\nAPI_URL=http://dev\nDEBUG=1\n"), 0644)
+	os.WriteFile(filepath.Join(second, ".env"), []byte("API_URL=http://prod\nPORT=4000\nDB_URL=postgres://prod\n"), 0644)
+
+	a, err := Resolve(first)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	b, err := Resolve(second)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	c := CompareIgnoring(a, b, []string{"*_URL"})
+	if strings.Join(c.Ignored, ",") != "API_URL,DB_URL" {
+		t.Errorf("Ignored = %v, want [API_URL DB_URL]", c.Ignored)
+	}
+	if len(c.OnlyInSecond) != 0 || strings.Join(c.OnlyInFirst, ",") != "DEBUG" {
+		t.Errorf("OnlyInFirst = %v, OnlyInSecond = %v, want [DEBUG] and []", c.OnlyInFirst, c.OnlyInSecond)
+	}
+	if c.Missing() != 1 {
+		t.Errorf("Missing() = %d, want 1", c.Missing())
+	}
+	if len(c.Different) != 1 {
+		t.Fatalf("Different = %+v, want PORT only", c.Different)
+	}
+	d := c.Different[0]
+	if d.FirstFrom.Line != 1 || d.SecondFrom.Line != 2 {
+		t.Errorf("PORT sources = %s and %s, want lines 1 and 2", d.FirstFrom.Location(), d.SecondFrom.Location())
+	}
+	if got, want := c.FirstFrom["DEBUG"].Location(), filepath.Join(first, ".env")+":3"; got != want {
+		t.Errorf("DEBUG location = %s, want %s", got, want)
+	}
+}
+
+func TestCheckPatterns(t *testing.T) {
+	if err := CheckPatterns([]string{"*_URL", "DB_?"}); err != nil {
+		t.Errorf("CheckPatterns = %v, want nil", err)
+	}
+	if err := CheckPatterns([]string{"*_URL", "["}); err == nil {
+		t.Error("CheckPatterns should reject a malformed pattern")
+	}
+}
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+)
+
+// FromExample reads schema annotations from comments in a .env.example
+// file. It returns nil without error when the file does not exist or
+// documents no variables.
+//
+// The comment block directly above a key describes it. Words starting
+// with @ are attributes, everything else is the description:
+//
+//	# @type=url @required @secret Primary database connection
+//	DATABASE_URL=
+func FromExample(path string) (*Schema, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+
+	doc, err := dotenv.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := New()
+	var block []*dotenv.Line
+
+	for _, l := range doc.Lines {
+		switch l.Kind {
+		case dotenv.LineComment:
+			if !isDirective(l) {
+				block = append(block, l)
+			}
+			continue
+		case dotenv.LineEntry:
+			if len(block) > 0 {
+				field, err := parseAnnotations(l.Key, block)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: %w", path, l.Number, err)
+				}
+				field.File = path
+				field.Line = l.Number
+				s.Add(field)
+			}
+		}
+		block = nil
+	}
+
+	if len(s.Order) == 0 {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func parseAnnotations(name string, comments []*dotenv.Line) (*Field, error) {
+	field := &Field{Name: name, Type: TypeString}
+	var words []string
+
+	// A heading such as "# Database" above an annotated line describes the
+	// section, not the key; start from the first annotated line if any
+	lines := make([][]string, len(comments))
+	start := 0
+	for i := len(comments) - 1; i >= 0; i-- {
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comments[i].Raw), "#"))
+		lines[i] = tokenize(text)
+		for _, tok := range lines[i] {
+			if strings.HasPrefix(tok, "@") && len(tok) > 1 {
+				start = i
+				break
+			}
+		}
+	}
+
+	for _, tokens := range lines[start:] {
+		for _, tok := range tokens {
+			if !strings.HasPrefix(tok, "@") || len(tok) == 1 {
+				words = append(words, tok)
+				continue
+			}
+			key, val, _ := strings.Cut(tok[1:], "=")
+			err := field.SetAttr(key, val)
+			if errors.Is(err, errUnknownAttr) {
+				// Free text such as "ask @ops" is part of the description
+				words = append(words, tok)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	field.Description = strings.Join(words, " ")
+	return field, nil
+}
+
+// isDirective reports comments addressed to envmerge itself, such as
+// "# envmerge-disable-next-line", which are not documentation
+func isDirective(l *dotenv.Line) bool {
+	text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l.Raw), "#"))
+	return strings.HasPrefix(text, "envmerge-")
+}
@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// jsonSchema is the subset of JSON Schema understood by envmerge: a
+// top-level object whose properties are the environment variables
+type jsonSchema struct {
+	Properties map[string]jsonProperty `json:"properties"`
+	Required   []string                `json:"required"`
+}
+
+type jsonProperty struct {
+	Type        string        `json:"type"`
+	Format      string        `json:"format"`
+	Description string        `json:"description"`
+	Default     interface{}   `json:"default"`
+	Pattern     string        `json:"pattern"`
+	Enum        []interface{} `json:"enum"`
+	Minimum     *float64      `json:"minimum"`
+	Maximum     *float64      `json:"maximum"`
+	MinLength   *float64      `json:"minLength"`
+	MaxLength   *float64      `json:"maxLength"`
+	Services    []string      `json:"x-services"`
+}
+
+func loadJSONSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var js jsonSchema
+	if err := json.Unmarshal(data, &js); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	required := make(map[string]bool)
+	for _, name := range js.Required {
+		required[name] = true
+	}
+
+	// JSON objects are unordered; keep output deterministic
+	names := make([]string, 0, len(js.Properties))
+	for name := range js.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := New()
+	for _, name := range names {
+		p := js.Properties[name]
+		field := &Field{
+			Name:        name,
+			Type:        jsonType(p.Type, p.Format),
+			Required:    required[name],
+			Description: p.Description,
+			Services:    p.Services,
+			File:        path,
+		}
+
+		if p.Default != nil {
+			d := fmt.Sprintf("%v", p.Default)
+			field.Default = &d
+		}
+		if p.Pattern != "" {
+			re, err := regexp.Compile(p.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern for %s: %w", path, name, err)
+			}
+			field.Pattern = re
+		}
+		for _, e := range p.Enum {
+			field.Enum = append(field.Enum, fmt.Sprintf("%v", e))
+		}
+
+		field.Min, field.Max = p.Minimum, p.Maximum
+		if field.Type == TypeString {
+			field.Min, field.Max = p.MinLength, p.MaxLength
+		}
+
+		s.Add(field)
+	}
+
+	return s, nil
+}
+
+func jsonType(typ, format string) Type {
+	switch typ {
+	case "integer":
+		if format == "port" {
+			return TypePort
+		}
+		return TypeInt
+	case "number":
+		return TypeNumber
+	case "boolean":
+		return TypeBool
+	}
+
+	switch format {
+	case "uri", "url":
+		return TypeURL
+	case "email":
+		return TypeEmail
+	case "duration":
+		return TypeDuration
+	}
+	return TypeString
+}
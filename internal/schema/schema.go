@@ -0,0 +1,334 @@
+// Package schema loads typed variable declarations and validates resolved values against them
+package schema
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Type is the declared value type of a variable
+type Type string
+
+const (
+	TypeString   Type = "string"
+	TypeInt      Type = "int"
+	TypeNumber   Type = "number"
+	TypeBool     Type = "bool"
+	TypeURL      Type = "url"
+	TypeEmail    Type = "email"
+	TypePort     Type = "port"
+	TypeDuration Type = "duration"
+)
+
+// Field declares the constraints for a single variable
+type Field struct {
+	Name        string
+	Type        Type
+	Required    bool
+	Default     *string
+	Pattern     *regexp.Regexp
+	Enum        []string
+	Min         *float64 // Numeric bound, or length bound for strings
+	Max         *float64
+	Services    []string // Empty means the field applies to every service
+	Secret      bool     // Value is sensitive and should not be shown
+	Description string
+	File        string // Where the declaration was read from
+	Line        int
+}
+
+// AppliesTo reports whether the field is checked for the given service
+func (f *Field) AppliesTo(service string) bool {
+	if len(f.Services) == 0 {
+		return true
+	}
+	for _, s := range f.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema is an ordered set of field declarations
+type Schema struct {
+	Fields map[string]*Field
+	Order  []string
+}
+
+// New returns an empty schema
+func New() *Schema {
+	return &Schema{Fields: make(map[string]*Field)}
+}
+
+// Add inserts or replaces a field, keeping first-seen order
+func (s *Schema) Add(f *Field) {
+	if _, ok := s.Fields[f.Name]; !ok {
+		s.Order = append(s.Order, f.Name)
+	}
+	s.Fields[f.Name] = f
+}
+
+// Merge adds fields from other that s does not declare, and fills in
+// missing descriptions and secret flags for fields both declare
+func (s *Schema) Merge(other *Schema) {
+	if other == nil {
+		return
+	}
+	for _, name := range other.Order {
+		f := other.Fields[name]
+		existing, ok := s.Fields[name]
+		if !ok {
+			s.Add(f)
+			continue
+		}
+		if existing.Description == "" {
+			existing.Description = f.Description
+		}
+		existing.Secret = existing.Secret || f.Secret
+	}
+}
+
+// Lookup returns the field for name, or nil
+func (s *Schema) Lookup(name string) *Field {
+	if s == nil {
+		return nil
+	}
+	return s.Fields[name]
+}
+
+// DefaultFiles are probed, in order, when no schema path is given
+var DefaultFiles = []string{".env.schema", ".env.schema.json", "env.schema.json"}
+
+// Find loads the first default schema file under basePath. It returns
+// nil without error when none exists
+func Find(basePath string) (*Schema, error) {
+	for _, name := range DefaultFiles {
+		path := filepath.Join(basePath, name)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+	return nil, nil
+}
+
+// Discover builds the schema for a project: the given schema file (or the
+// first default file found), merged with annotations from .env.example.
+// It returns nil without error when the project declares nothing
+func Discover(basePath, schemaPath string) (*Schema, error) {
+	var s *Schema
+	var err error
+	if schemaPath != "" {
+		s, err = Load(schemaPath)
+	} else {
+		s, err = Find(basePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	annotated, err := FromExample(filepath.Join(basePath, ".env.example"))
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return annotated, nil
+	}
+	s.Merge(annotated)
+	return s, nil
+}
+
+// Load reads a schema file; .json files are treated as JSON Schema,
+// anything else as the .env.schema line format
+func Load(path string) (*Schema, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return loadJSONSchema(path)
+	}
+	return loadEnvSchema(path)
+}
+
+// loadEnvSchema parses the .env.schema format: one variable per line,
+// followed by space-separated attributes. Lines starting with # are
+// comments; a comment directly above a variable becomes its description.
+//
+//	# Public URL of the API
+//	API_URL      type=url required
+//	PORT         type=port default=3000
+//	LOG_LEVEL    enum=debug,info,warn,error default=info
+//	WORKER_COUNT type=int min=1 max=64 services=worker
+func loadEnvSchema(path string) (*Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := New()
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	var comment []string
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			comment = nil
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
+
+		tokens := tokenize(line)
+		field := &Field{
+			Name:        tokens[0],
+			Type:        TypeString,
+			Description: strings.Join(comment, " "),
+			File:        path,
+			Line:        lineNum,
+		}
+		comment = nil
+
+		for _, tok := range tokens[1:] {
+			key, val, _ := strings.Cut(tok, "=")
+			if err := field.SetAttr(key, val); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+			}
+		}
+		s.Add(field)
+	}
+
+	return s, scanner.Err()
+}
+
+var errUnknownAttr = errors.New("unknown attribute")
+
+// SetAttr applies one key=value attribute to the field. Flags such as
+// "required" are passed with an empty value
+func (f *Field) SetAttr(key, val string) error {
+	switch strings.ToLower(key) {
+	case "type":
+		t, err := ParseType(val)
+		if err != nil {
+			return err
+		}
+		f.Type = t
+	case "required":
+		f.Required = val == "" || val == "true"
+	case "optional":
+		f.Required = false
+	case "default":
+		v := unquote(val)
+		f.Default = &v
+	case "pattern":
+		re, err := regexp.Compile(unquote(val))
+		if err != nil {
+			return fmt.Errorf("invalid pattern for %s: %w", f.Name, err)
+		}
+		f.Pattern = re
+	case "enum":
+		f.Enum = splitList(unquote(val))
+	case "min", "max":
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s for %s: %q", key, f.Name, val)
+		}
+		if key == "min" {
+			f.Min = &n
+		} else {
+			f.Max = &n
+		}
+	case "services", "service":
+		f.Services = splitList(val)
+	case "secret":
+		f.Secret = val == "" || val == "true"
+	case "description", "desc":
+		f.Description = unquote(val)
+	default:
+		return fmt.Errorf("%w %q for %s", errUnknownAttr, key, f.Name)
+	}
+	return nil
+}
+
+// ParseType maps a type name (including common aliases) to a Type
+func ParseType(name string) (Type, error) {
+	switch strings.ToLower(name) {
+	case "string", "str", "":
+		return TypeString, nil
+	case "int", "integer":
+		return TypeInt, nil
+	case "number", "float":
+		return TypeNumber, nil
+	case "bool", "boolean":
+		return TypeBool, nil
+	case "url", "uri":
+		return TypeURL, nil
+	case "email":
+		return TypeEmail, nil
+	case "port":
+		return TypePort, nil
+	case "duration":
+		return TypeDuration, nil
+	default:
+		return "", fmt.Errorf("unknown type %q", name)
+	}
+}
+
+// tokenize splits on whitespace while keeping quoted attribute values intact
+func tokenize(line string) []string {
+	var tokens []string
+	var cur strings.Builder
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			cur.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t':
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 {
+		if (s[0] == '"' && s[len(s)-1] == '"') ||
+			(s[0] == '\'' && s[len(s)-1] == '\'') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
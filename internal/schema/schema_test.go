@@ -0,0 +1,224 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_EnvSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, ".env.schema", `# Public API address
+API_URL   type=url required
+LOG_LEVEL enum=debug,info,warn default="info"
+WORKERS   type=int min=1 max=64 services=worker,cron
+`)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	api := s.Lookup("API_URL")
+	if api == nil || api.Type != TypeURL || !api.Required {
+		t.Fatalf("API_URL = %+v, want required url", api)
+	}
+	if api.Description != "Public API address" {
+		t.Errorf("API_URL description = %q", api.Description)
+	}
+	if f := s.Lookup("LOG_LEVEL"); f.Default == nil || *f.Default != "info" || len(f.Enum) != 3 {
+		t.Errorf("LOG_LEVEL = %+v, want enum of 3 with default info", f)
+	}
+	if f := s.Lookup("WORKERS"); *f.Max != 64 || !f.AppliesTo("cron") || f.AppliesTo("api") {
+		t.Errorf("WORKERS = %+v", f)
+	}
+}
+
+func TestLoad_UnknownAttribute(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, ".env.schema", "PORT type=int colour=blue\n")
+
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for unknown attribute")
+	}
+}
+
+func TestLoad_JSONSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "env.schema.json", `{
+  "type": "object",
+  "required": ["DATABASE_URL"],
+  "properties": {
+    "DATABASE_URL": {"type": "string", "format": "uri"},
+    "PORT": {"type": "integer", "minimum": 1, "maximum": 65535, "default": 3000},
+    "MODE": {"type": "string", "enum": ["dev", "prod"], "x-services": ["api"]}
+  }
+}`)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if f := s.Lookup("DATABASE_URL"); f.Type != TypeURL || !f.Required {
+		t.Errorf("DATABASE_URL = %+v, want required url", f)
+	}
+	if f := s.Lookup("PORT"); f.Type != TypeInt || *f.Default != "3000" {
+		t.Errorf("PORT = %+v, want int with default 3000", f)
+	}
+	if f := s.Lookup("MODE"); len(f.Enum) != 2 || !f.AppliesTo("api") {
+		t.Errorf("MODE = %+v", f)
+	}
+}
+
+func TestValidate_ReportsSourceLocation(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "PORT=3000\nLOG_LEVEL=verbose\nAPI_URL=not a url\n")
+	writeFile(t, dir, "docker-compose.yml", `services:
+  api:
+    environment:
+      PORT: "http"
+  web:
+    image: nginx
+`)
+	schemaPath := writeFile(t, dir, ".env.schema", `PORT      type=port
+LOG_LEVEL enum=debug,info
+API_URL   type=url
+SECRET    required
+`)
+
+	s, err := Load(schemaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := resolver.Resolve(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]resolver.Violation)
+	for _, v := range Validate(s, r) {
+		got[v.Variable+"/"+v.Rule] = v
+	}
+
+	if len(got) != 4 {
+		t.Errorf("got %d violations, want 4: %+v", len(got), got)
+	}
+
+	port, ok := got["PORT/type"]
+	if !ok {
+		t.Fatal("missing PORT type violation")
+	}
+	if port.Service != "api" || port.Source.Layer != resolver.LayerComposeInline {
+		t.Errorf("PORT violation = %+v, want service api from compose inline", port)
+	}
+
+	level, ok := got["LOG_LEVEL/enum"]
+	if !ok {
+		t.Fatal("missing LOG_LEVEL enum violation")
+	}
+	if level.Source.Line != 2 || filepath.Base(level.Source.File) != ".env" {
+		t.Errorf("LOG_LEVEL location = %s:%d, want .env:2", level.Source.File, level.Source.Line)
+	}
+
+	if _, ok := got["SECRET/required"]; !ok {
+		t.Error("missing SECRET required violation")
+	}
+}
+
+func TestCheckValue_Types(t *testing.T) {
+	tests := []struct {
+		typ   Type
+		value string
+		ok    bool
+	}{
+		{TypeInt, "42", true},
+		{TypeInt, "4.2", false},
+		{TypeNumber, "4.2", true},
+		{TypeBool, "TRUE", true},
+		{TypeBool, "yes", false},
+		{TypePort, "8080", true},
+		{TypePort, "70000", false},
+		{TypeURL, "https://example.com/x", true},
+		{TypeURL, "example.com", false},
+		{TypeEmail, "ops@example.com", true},
+		{TypeDuration, "1m30s", true},
+		{TypeDuration, "90", false},
+	}
+
+	for _, tc := range tests {
+		f := &Field{Name: "X", Type: tc.typ}
+		rule, _ := f.checkValue(tc.value, false)
+		if (rule == "") != tc.ok {
+			t.Errorf("%s %q: rule = %q, want ok=%v", tc.typ, tc.value, rule, tc.ok)
+		}
+	}
+}
+
+func TestFromExample_Annotations(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, ".env.example", `# Database
+# @type=url @required @secret Primary database connection
+DATABASE_URL=
+
+# Ask @ops for access
+LOG_LEVEL=info
+
+PLAIN=1
+`)
+
+	s, err := FromExample(path)
+	if err != nil {
+		t.Fatalf("FromExample failed: %v", err)
+	}
+
+	db := s.Lookup("DATABASE_URL")
+	if db == nil {
+		t.Fatal("DATABASE_URL not annotated")
+	}
+	if db.Type != TypeURL || !db.Required || !db.Secret {
+		t.Errorf("DATABASE_URL = %+v, want required secret url", db)
+	}
+	if db.Description != "Primary database connection" {
+		t.Errorf("DATABASE_URL description = %q", db.Description)
+	}
+	if db.Line != 3 {
+		t.Errorf("DATABASE_URL line = %d, want 3", db.Line)
+	}
+
+	if f := s.Lookup("LOG_LEVEL"); f == nil || f.Description != "Ask @ops for access" {
+		t.Errorf("LOG_LEVEL = %+v, want free-text description", f)
+	}
+	if s.Lookup("PLAIN") != nil {
+		t.Error("PLAIN has no comment and should not be in the schema")
+	}
+}
+
+func TestDiscover_MergesExampleAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".env.schema", "PORT type=port\n")
+	writeFile(t, dir, ".env.example", "# HTTP listen port\nPORT=3000\n# @required\nAPI_KEY=\n")
+
+	s, err := Discover(dir, "")
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	if f := s.Lookup("PORT"); f.Type != TypePort || f.Description != "HTTP listen port" {
+		t.Errorf("PORT = %+v, want port type with example description", f)
+	}
+	if f := s.Lookup("API_KEY"); f == nil || !f.Required {
+		t.Errorf("API_KEY = %+v, want required from annotation", f)
+	}
+}
@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// Annotate copies descriptions and secret flags from the schema onto the
+// matching resolved variables so reporters can show them
+func Annotate(s *Schema, r *resolver.Resolution) {
+	if s == nil {
+		return
+	}
+	for _, name := range s.Order {
+		if v, ok := r.ByName[name]; ok {
+			f := s.Fields[name]
+			v.Description = f.Description
+			v.Secret = v.Secret || f.Secret
+		}
+	}
+}
+
+// Validate checks every declared field against the resolution, records
+// the failures on r.Violations and returns them. Messages leave out the
+// values of secret fields and of variables already marked secret, so run
+// secrets.Mark first
+func Validate(s *Schema, r *resolver.Resolution) []resolver.Violation {
+	if s == nil {
+		return nil
+	}
+
+	services := r.Services()
+	var violations []resolver.Violation
+
+	for _, name := range s.Order {
+		f := s.Fields[name]
+		v := r.ByName[name]
+
+		if len(f.Services) == 0 {
+			violations = append(violations, f.check(v, "")...)
+			if v == nil {
+				continue
+			}
+			// A service may see a different value than the project-wide
+			// winner; validate those views separately
+			for _, svc := range services {
+				if src, ok := v.ForService(svc); ok && src != v.FinalFrom {
+					violations = append(violations, f.check(v, svc)...)
+				}
+			}
+			continue
+		}
+
+		for _, svc := range f.Services {
+			if len(services) > 0 && !contains(services, svc) {
+				continue
+			}
+			violations = append(violations, f.check(v, svc)...)
+		}
+	}
+
+	r.Violations = append(r.Violations, violations...)
+	return violations
+}
+
+// check validates the value a service (or the whole project, when service
+// is empty) receives for the field
+func (f *Field) check(v *resolver.Variable, service string) []resolver.Violation {
+	var src resolver.Source
+	found := false
+	if v != nil {
+		if service == "" {
+			src, found = v.FinalFrom, len(v.Chain) > 0
+		} else {
+			src, found = v.ForService(service)
+		}
+	}
+
+	if service == "" {
+		service = src.Service
+	}
+	violation := func(rule, format string, args ...interface{}) []resolver.Violation {
+		return []resolver.Violation{{
+			Variable: f.Name,
+			Service:  service,
+			Rule:     rule,
+			Message:  fmt.Sprintf(format, args...),
+			Source:   src,
+		}}
+	}
+
+	if !found {
+		if f.Required && f.Default == nil {
+			return violation("required", "required variable is not defined")
+		}
+		return nil
+	}
+
+	// An encrypted value nobody could decrypt is set, just unknown
+	if src.Unresolvable {
+		return nil
+	}
+
+	if src.Value == "" {
+		if f.Required && f.Default == nil {
+			return violation("required", "required variable is empty")
+		}
+		return nil
+	}
+
+	if rule, msg := f.checkValue(src.Value, f.Secret || v.Secret); rule != "" {
+		return violation(rule, "%s", msg)
+	}
+	return nil
+}
+
+// checkValue returns the failing rule and a message, or empty strings.
+// With hide set the message leaves the value out
+func (f *Field) checkValue(value string, hide bool) (string, string) {
+	var num float64
+	isNumeric := false
+
+	switch f.Type {
+	case TypeInt, TypePort:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "type", fmt.Sprintf("%s is not an integer", show(value, hide))
+		}
+		if f.Type == TypePort && (n < 1 || n > 65535) {
+			return "type", fmt.Sprintf("%d is not a valid port (1-65535)", n)
+		}
+		num, isNumeric = float64(n), true
+	case TypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "type", fmt.Sprintf("%s is not a number", show(value, hide))
+		}
+		num, isNumeric = n, true
+	case TypeBool:
+		if _, err := strconv.ParseBool(strings.ToLower(value)); err != nil {
+			return "type", fmt.Sprintf("%s is not a boolean", show(value, hide))
+		}
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+			return "type", fmt.Sprintf("%s is not a valid URL", show(value, hide))
+		}
+	case TypeEmail:
+		if _, err := mail.ParseAddress(value); err != nil {
+			return "type", fmt.Sprintf("%s is not a valid email address", show(value, hide))
+		}
+	case TypeDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return "type", fmt.Sprintf("%s is not a valid duration", show(value, hide))
+		}
+	}
+
+	if len(f.Enum) > 0 && !contains(f.Enum, value) {
+		return "enum", fmt.Sprintf("%s is not one of: %s", show(value, hide), strings.Join(f.Enum, ", "))
+	}
+
+	if f.Pattern != nil && !f.Pattern.MatchString(value) {
+		return "pattern", fmt.Sprintf("%s does not match pattern %s", show(value, hide), f.Pattern)
+	}
+
+	if !isNumeric {
+		num = float64(utf8.RuneCountInString(value))
+	}
+	what := "value"
+	if !isNumeric {
+		what = "length"
+	}
+	if f.Min != nil && num < *f.Min {
+		return "min", fmt.Sprintf("%s %g is below minimum %g", what, num, *f.Min)
+	}
+	if f.Max != nil && num > *f.Max {
+		return "max", fmt.Sprintf("%s %g is above maximum %g", what, num, *f.Max)
+	}
+
+	return "", ""
+}
+
+// show quotes a value for a message, or hides it
+func show(value string, hide bool) string {
+	if hide {
+		return "value"
+	}
+	return strconv.Quote(value)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
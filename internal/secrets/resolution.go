@@ -0,0 +1,109 @@
+package secrets
+
+import (
+	"strings"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+)
+
+// Mark flags every variable whose name or any candidate value looks secret.
+// Variables already marked (e.g. by a schema) stay marked
+func Mark(r *resolver.Resolution) {
+	for _, v := range r.ByName {
+		if v.Secret {
+			continue
+		}
+		if _, ok := Classify(v.Name, ""); ok {
+			v.Secret = true
+			continue
+		}
+		for _, src := range v.Chain {
+			if _, ok := ClassifyValue(src.Value); ok {
+				v.Secret = true
+				break
+			}
+		}
+	}
+}
+
+// Redact returns a copy of r in which every value of a secret variable is
+// masked. The original resolution is not modified
+func Redact(r *resolver.Resolution) *resolver.Resolution {
+	return RedactIf(r, func(v *resolver.Variable) bool { return v.Secret })
+}
+
+// RedactEncrypted masks only variables with a value decrypted from an
+// encrypted file
+func RedactEncrypted(r *resolver.Resolution) *resolver.Resolution {
+	return RedactIf(r, (*resolver.Variable).IsEncrypted)
+}
+
+// RedactIf is Redact for the variables selected by mask
+func RedactIf(r *resolver.Resolution, mask func(*resolver.Variable) bool) *resolver.Resolution {
+	out := *r
+	out.ByName = make(map[string]*resolver.Variable, len(r.ByName))
+	out.Variables = make([]*resolver.Variable, 0, len(r.Variables))
+
+	var masked []string // Raw secret values, to scrub from free text
+	copies := make(map[*resolver.Variable]*resolver.Variable, len(r.ByName))
+	for name, v := range r.ByName {
+		c := *v
+		if mask(v) {
+			c.FinalValue = Mask(v.FinalValue)
+			c.FinalFrom = maskSource(v.FinalFrom)
+			c.Chain = make([]resolver.Source, len(v.Chain))
+			for i, src := range v.Chain {
+				c.Chain[i] = maskSource(src)
+				if src.Value != "" {
+					masked = append(masked, src.Value)
+				}
+			}
+			c.Conflicts = make([]string, len(v.Conflicts))
+			for i, val := range v.Conflicts {
+				c.Conflicts[i] = Mask(val)
+			}
+		}
+		copies[v] = &c
+		out.ByName[name] = &c
+	}
+	for _, v := range r.Variables {
+		if c, ok := copies[v]; ok {
+			out.Variables = append(out.Variables, c)
+		}
+	}
+
+	out.Violations = make([]resolver.Violation, len(r.Violations))
+	for i, viol := range r.Violations {
+		if v := r.ByName[viol.Variable]; v != nil && mask(v) {
+			viol.Source = maskSource(viol.Source)
+		}
+		viol.Message = scrub(viol.Message, masked)
+		out.Violations[i] = viol
+	}
+
+	out.Warnings = make([]string, len(r.Warnings))
+	for i, w := range r.Warnings {
+		out.Warnings[i] = scrub(w, masked)
+	}
+
+	return &out
+}
+
+func maskSource(s resolver.Source) resolver.Source {
+	s.Value = Mask(s.Value)
+	return s
+}
+
+// minScrubLength avoids masking every "1" in a message because some
+// secret happened to be that short
+const minScrubLength = 4
+
+// scrub replaces any secret value embedded in free text with its mask
+func scrub(text string, values []string) string {
+	for _, val := range values {
+		if len(val) >= minScrubLength && strings.Contains(text, val) {
+			text = strings.ReplaceAll(text, val, Mask(val))
+		}
+	}
+	return text
+}
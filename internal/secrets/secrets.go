@@ -0,0 +1,142 @@
+// Package secrets classifies sensitive environment values and redacts them
+package secrets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Finding explains why a variable was classified as secret
+type Finding struct {
+	Reason string // Human-readable reason, e.g. "GitHub token"
+	ByName bool   // Matched on the variable name rather than the value
+}
+
+// namePatterns match variable names that conventionally hold secrets
+var namePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|_)(SECRET|SECRETS)(_|$)`),
+	regexp.MustCompile(`(^|_)(PASSWORD|PASSWD|PASS|PWD)(_|$)`),
+	regexp.MustCompile(`(^|_)(TOKEN|TOKENS)(_|$)`),
+	regexp.MustCompile(`(^|_)(KEY|APIKEY)$`),
+	regexp.MustCompile(`(^|_)(PRIVATE|CREDENTIALS?|AUTH)(_|$)`),
+	regexp.MustCompile(`(^|_)(DSN|SALT|PEPPER|COOKIE_SECRET|SIGNING)(_|$)`),
+}
+
+// valuePattern is a known token format
+type valuePattern struct {
+	reason string
+	re     *regexp.Regexp
+}
+
+var valuePatterns = []valuePattern{
+	{"private key block", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b(sk|rk)_(live|test)_[A-Za-z0-9]{16,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)},
+	{"credentials in URL", regexp.MustCompile(`[a-z][a-z0-9+.\-]*://[^/\s:@]+:[^/\s@]+@`)},
+}
+
+// Entropy thresholds for random-looking strings
+const (
+	minEntropyLength = 20
+	minEntropy       = 4.0
+)
+
+// Classify reports whether a variable looks secret, based on its name and
+// value. Values are checked first so the reason names the token type
+func Classify(name, value string) (Finding, bool) {
+	if f, ok := ClassifyValue(value); ok {
+		return f, true
+	}
+
+	upper := strings.ToUpper(name)
+	for _, re := range namePatterns {
+		if re.MatchString(upper) {
+			return Finding{Reason: "name suggests a secret", ByName: true}, true
+		}
+	}
+	return Finding{}, false
+}
+
+// ClassifyValue checks only the value against known formats and entropy
+func ClassifyValue(value string) (Finding, bool) {
+	if value == "" {
+		return Finding{}, false
+	}
+	for _, p := range valuePatterns {
+		if p.re.MatchString(value) {
+			return Finding{Reason: p.reason}, true
+		}
+	}
+	if isHighEntropy(value) {
+		return Finding{Reason: "high-entropy string"}, true
+	}
+	return Finding{}, false
+}
+
+// isHighEntropy flags long single-token strings whose characters look random
+func isHighEntropy(s string) bool {
+	if len(s) < minEntropyLength || strings.ContainsAny(s, " \t/") {
+		return false
+	}
+	if strings.Contains(s, "://") {
+		return false
+	}
+	return ShannonEntropy(s) >= minEntropy
+}
+
+// ShannonEntropy returns the bits of entropy per character of s
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// Fingerprint returns a short stable hash of value, so two redacted values
+// can be compared without revealing either
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// Mask replaces a value with a fixed marker and its fingerprint. Empty
+// values stay empty so "unset" remains visible
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[redacted:" + Fingerprint(value) + "]"
+}
+
+// Minimum length before Preview reveals a prefix
+const minPreviewLength = 16
+
+// Preview is Mask with the first few characters kept for long values, so a
+// reader can tell which credential leaked ("sk_l…[redacted:1a2b3c4d]")
+// without the value being recoverable
+func Preview(value string) string {
+	runes := []rune(value)
+	if len(runes) < minPreviewLength {
+		return Mask(value)
+	}
+	return string(runes[:4]) + "…" + Mask(value)
+}
@@ -0,0 +1,168 @@
+// Package shell renders environment variables as export statements that
+// are safe to eval in each supported shell
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Supported shells
+const (
+	Bash       = "bash"
+	Zsh        = "zsh"
+	Fish       = "fish"
+	PowerShell = "pwsh"
+	Nushell    = "nu"
+)
+
+// aliases maps accepted spellings onto a supported shell
+var aliases = map[string]string{
+	"bash":       Bash,
+	"sh":         Bash,
+	"zsh":        Zsh,
+	"fish":       Fish,
+	"pwsh":       PowerShell,
+	"powershell": PowerShell,
+	"nu":         Nushell,
+	"nushell":    Nushell,
+}
+
+// Names lists the shells accepted by Format
+func Names() []string {
+	return []string{Bash, Zsh, Fish, PowerShell, Nushell}
+}
+
+// Normalize returns the supported shell for name, or an error
+func Normalize(name string) (string, error) {
+	if s, ok := aliases[strings.ToLower(name)]; ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown shell %q (supported: %s)", name, strings.Join(Names(), ", "))
+}
+
+// validName is what every supported shell accepts as a variable name
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Format renders env as export statements and unset as statements removing
+// those variables, sorted by name. Names no shell can set are returned in
+// skipped rather than emitted
+func Format(shell string, env map[string]string, unset []string) (out string, skipped []string, err error) {
+	shell, err = Normalize(shell)
+	if err != nil {
+		return "", nil, err
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	unset = append([]string(nil), unset...)
+	sort.Strings(unset)
+
+	var sb strings.Builder
+	for _, name := range names {
+		if !validName.MatchString(name) {
+			skipped = append(skipped, name)
+			continue
+		}
+		sb.WriteString(set(shell, name, env[name]))
+		sb.WriteString("\n")
+	}
+	for _, name := range unset {
+		if !validName.MatchString(name) {
+			skipped = append(skipped, name)
+			continue
+		}
+		sb.WriteString(remove(shell, name))
+		sb.WriteString("\n")
+	}
+	return sb.String(), skipped, nil
+}
+
+func set(shell, name, value string) string {
+	switch shell {
+	case Fish:
+		return fmt.Sprintf("set -gx %s %s", name, QuoteFish(value))
+	case PowerShell:
+		return fmt.Sprintf("$env:%s = %s", name, QuotePowerShell(value))
+	case Nushell:
+		return fmt.Sprintf("$env.%s = %s", name, QuoteNushell(value))
+	default:
+		return fmt.Sprintf("export %s=%s", name, QuotePOSIX(value))
+	}
+}
+
+func remove(shell, name string) string {
+	switch shell {
+	case Fish:
+		return "set -e " + name
+	case PowerShell:
+		return fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", name)
+	case Nushell:
+		return "hide-env -i " + name
+	default:
+		return "unset " + name
+	}
+}
+
+// QuotePOSIX single-quotes s for sh, bash and zsh. Nothing is special
+// inside single quotes, so only the quote itself needs care: it closes the
+// string, adds an escaped quote and reopens it
+func QuotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteFish single-quotes s for fish, where \ and ' are the only escapes
+func QuoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// QuotePowerShell single-quotes s for PowerShell, doubling embedded quotes.
+// PowerShell also treats typographic single quotes as quote characters
+func QuotePowerShell(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '‘', '’', '‚', '‛':
+			sb.WriteRune(r)
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
+
+// QuoteNushell double-quotes s for nushell, escaping backslashes, quotes
+// and control characters
+func QuoteNushell(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u{%x}`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
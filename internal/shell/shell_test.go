@@ -0,0 +1,72 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+var tricky = map[string]string{
+	"SPACES":    "hello world",
+	"QUOTES":    `it's "quoted"`,
+	"HASH":      "a # not a comment",
+	"NEWLINE":   "line1\nline2",
+	"DOLLAR":    "$HOME and $(whoami) and `id`",
+	"BACKSLASH": `C:\path\to\n`,
+	"EMPTY":     "",
+}
+
+func TestFormat_BashRoundTrip(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+
+	out, skipped, err := Format(Bash, tricky, []string{"STALE"})
+	if err != nil || len(skipped) > 0 {
+		t.Fatalf("Format = %v, skipped %v", err, skipped)
+	}
+
+	for name, want := range tricky {
+		script := "export STALE=old\n" + out + `printf '%s' "$` + name + `"; printf '|%s' "${STALE-unset}"`
+		got, err := exec.Command(bash, "-c", script).Output()
+		if err != nil {
+			t.Fatalf("bash failed: %v\n%s", err, out)
+		}
+		if string(got) != want+"|unset" {
+			t.Errorf("%s = %q, want %q", name, got, want+"|unset")
+		}
+	}
+}
+
+func TestFormat_Shells(t *testing.T) {
+	env := map[string]string{"A": `it's \ "x"` + "\n"}
+	tests := map[string]string{
+		"sh":         `export A='it'\''s \ "x"` + "\n'\nunset B\n",
+		"fish":       `set -gx A 'it\'s \\ "x"` + "\n'\nset -e B\n",
+		"powershell": `$env:A = 'it''s \ "x"` + "\n'\nRemove-Item Env:B -ErrorAction SilentlyContinue\n",
+		"nu":         `$env.A = "it's \\ \"x\"\n"` + "\nhide-env -i B\n",
+	}
+	for sh, want := range tests {
+		got, _, err := Format(sh, env, []string{"B"})
+		if err != nil {
+			t.Fatalf("Format(%s) failed: %v", sh, err)
+		}
+		if got != want {
+			t.Errorf("Format(%s) = %q, want %q", sh, got, want)
+		}
+	}
+}
+
+func TestFormat_InvalidNames(t *testing.T) {
+	out, skipped, err := Format(Bash, map[string]string{"OK": "1", "NOT-OK": "2"}, []string{"1BAD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "NOT-OK") || strings.Join(skipped, ",") != "NOT-OK,1BAD" {
+		t.Errorf("out = %q, skipped = %v", out, skipped)
+	}
+	if _, _, err := Format("tcsh", nil, nil); err == nil {
+		t.Error("unknown shell should fail")
+	}
+}
@@ -0,0 +1,351 @@
+// Package sops reads SOPS-encrypted dotenv, YAML and JSON files and
+// decrypts them locally with age identities
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/envmerge/internal/dotenv"
+)
+
+// Formats SOPS can store
+const (
+	FormatDotenv = "dotenv"
+	FormatYAML   = "yaml"
+	FormatJSON   = "json"
+)
+
+// KeyFileEnv names the age identity file, as in the sops CLI
+const KeyFileEnv = "SOPS_AGE_KEY_FILE"
+
+// ErrNoIdentity is returned when a file needs decrypting but no age
+// identity was configured
+var ErrNoIdentity = errors.New("no age identity (set " + KeyFileEnv + " or --age-key)")
+
+// Value is one top-level key of an encrypted file
+type Value struct {
+	Key       string
+	Line      int
+	Value     string // Plaintext, or the ENC[...] ciphertext until decrypted
+	Encrypted bool   // Stored encrypted (as opposed to left in the clear by SOPS)
+}
+
+// File is a parsed SOPS file
+type File struct {
+	Path      string
+	Format    string
+	Values    []Value
+	Decrypted bool
+
+	ageKeys []string // Armored age files wrapping the data key
+}
+
+// encRe matches an encrypted leaf: ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]
+var encRe = regexp.MustCompile(`^ENC\[AES256_GCM,data:([^,]*),iv:([^,]+),tag:([^,]+),type:([a-z]+)\]$`)
+
+// IsEncrypted reports whether data is a SOPS file, and in which format
+func IsEncrypted(data []byte) (string, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		if hasMetadata(data) {
+			return FormatJSON, true
+		}
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "sops_version=") || strings.HasPrefix(line, "sops_mac=") {
+			return FormatDotenv, true
+		}
+	}
+	if hasMetadata(data) {
+		return FormatYAML, true
+	}
+	return "", false
+}
+
+// hasMetadata checks for a top-level "sops" mapping in YAML or JSON
+func hasMetadata(data []byte) bool {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	meta, ok := doc["sops"].(map[string]interface{})
+	return ok && (meta["version"] != nil || meta["mac"] != nil)
+}
+
+// ParseFile reads and parses a SOPS file without decrypting it
+func ParseFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
+
+// Parse reads the keys and age metadata of a SOPS file
+func Parse(path string, data []byte) (*File, error) {
+	format, ok := IsEncrypted(data)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a SOPS file", path)
+	}
+
+	f := &File{Path: path, Format: format}
+	var err error
+	if format == FormatDotenv {
+		err = f.parseDotenv(data)
+	} else {
+		err = f.parseTree(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) parseDotenv(data []byte) error {
+	doc, err := dotenv.Parse(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	// Metadata is flattened as sops_age__list_0__map_enc=...
+	ageEnc := make(map[string]string)
+	for _, l := range doc.Lines {
+		if l.Kind != dotenv.LineEntry {
+			continue
+		}
+		if strings.HasPrefix(l.Key, "sops_") {
+			if strings.HasPrefix(l.Key, "sops_age__list_") && strings.HasSuffix(l.Key, "__map_enc") {
+				ageEnc[l.Key] = strings.ReplaceAll(l.Value, `\n`, "\n")
+			}
+			continue
+		}
+		f.Values = append(f.Values, Value{
+			Key:       l.Key,
+			Line:      l.Number,
+			Value:     l.Value,
+			Encrypted: encRe.MatchString(l.Value),
+		})
+	}
+	for i := 0; ; i++ {
+		enc, ok := ageEnc[fmt.Sprintf("sops_age__list_%d__map_enc", i)]
+		if !ok {
+			break
+		}
+		f.ageKeys = append(f.ageKeys, enc)
+	}
+	return nil
+}
+
+func (f *File) parseTree(data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: top level is not a mapping", f.Path)
+	}
+
+	m := root.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, val := m.Content[i], m.Content[i+1]
+		if key.Value == "sops" {
+			f.parseMetadata(val)
+			continue
+		}
+		// Only top-level scalars map onto environment variables
+		if val.Kind != yaml.ScalarNode {
+			continue
+		}
+		f.Values = append(f.Values, Value{
+			Key:       key.Value,
+			Line:      key.Line,
+			Value:     val.Value,
+			Encrypted: encRe.MatchString(val.Value),
+		})
+	}
+	return nil
+}
+
+func (f *File) parseMetadata(meta *yaml.Node) {
+	var md struct {
+		Age []struct {
+			Enc string `yaml:"enc"`
+		} `yaml:"age"`
+	}
+	if err := meta.Decode(&md); err != nil {
+		return
+	}
+	for _, a := range md.Age {
+		f.ageKeys = append(f.ageKeys, a.Enc)
+	}
+}
+
+// EncryptedKeys returns the keys stored encrypted, in file order
+func (f *File) EncryptedKeys() []string {
+	return f.keys(true)
+}
+
+// PlaintextKeys returns the keys SOPS left in the clear, in file order
+func (f *File) PlaintextKeys() []string {
+	return f.keys(false)
+}
+
+func (f *File) keys(encrypted bool) []string {
+	var keys []string
+	for _, v := range f.Values {
+		if v.Encrypted == encrypted {
+			keys = append(keys, v.Key)
+		}
+	}
+	return keys
+}
+
+// Decrypt unwraps the data key with one of identities and decrypts every
+// encrypted value in place. The file MAC is not verified
+func (f *File) Decrypt(identities []age.Identity) error {
+	if len(f.EncryptedKeys()) == 0 {
+		f.Decrypted = true
+		return nil
+	}
+	if len(identities) == 0 {
+		return ErrNoIdentity
+	}
+	if len(f.ageKeys) == 0 {
+		return errors.New("file has no age recipients")
+	}
+
+	dataKey, err := f.dataKey(identities)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range f.Values {
+		if !v.Encrypted {
+			continue
+		}
+		// Top-level keys, so the authenticated path is just "KEY:"
+		plain, err := decryptValue(v.Value, dataKey, v.Key+":")
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.Key, err)
+		}
+		if f.Format == FormatDotenv {
+			// SOPS encrypts the raw text after "=", quotes included
+			plain = dotenv.Unquote(strings.TrimSpace(plain))
+		}
+		f.Values[i].Value = plain
+	}
+	f.Decrypted = true
+	return nil
+}
+
+func (f *File) dataKey(identities []age.Identity) ([]byte, error) {
+	var lastErr error
+	for _, enc := range f.ageKeys {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(enc)), identities...)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return io.ReadAll(r)
+	}
+	return nil, fmt.Errorf("no matching age identity: %w", lastErr)
+}
+
+func decryptValue(value string, key []byte, additionalData string) (string, error) {
+	m := encRe.FindStringSubmatch(value)
+	if m == nil {
+		return "", errors.New("malformed ENC value")
+	}
+	data, err := base64.StdEncoding.DecodeString(m[1])
+	if err != nil {
+		return "", err
+	}
+	iv, err := base64.StdEncoding.DecodeString(m[2])
+	if err != nil {
+		return "", err
+	}
+	tag, err := base64.StdEncoding.DecodeString(m[3])
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", err
+	}
+	plain, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", errors.New("decryption failed")
+	}
+	// int and float are stored as text already; bools are Python-style
+	if m[4] == "bool" {
+		return strings.ToLower(string(plain)), nil
+	}
+	return string(plain), nil
+}
+
+// LoadIdentities reads age identities from keyFile, or from the file named
+// by SOPS_AGE_KEY_FILE when keyFile is empty. It returns nil when neither
+// is set
+func LoadIdentities(keyFile string) ([]age.Identity, error) {
+	if keyFile == "" {
+		keyFile = os.Getenv(KeyFileEnv)
+	}
+	if keyFile == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ids, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyFile, err)
+	}
+	return ids, nil
+}
+
+// Keyring loads age identities on first use, so projects without
+// encrypted files never touch the key file
+type Keyring struct {
+	KeyFile string // Explicit identity file; empty falls back to SOPS_AGE_KEY_FILE
+
+	once sync.Once
+	ids  []age.Identity
+	err  error
+}
+
+// NewKeyring returns a keyring reading keyFile or SOPS_AGE_KEY_FILE
+func NewKeyring(keyFile string) *Keyring {
+	return &Keyring{KeyFile: keyFile}
+}
+
+// Identities returns the loaded identities
+func (k *Keyring) Identities() ([]age.Identity, error) {
+	k.once.Do(func() {
+		k.ids, k.err = LoadIdentities(k.KeyFile)
+	})
+	return k.ids, k.err
+}
@@ -0,0 +1,235 @@
+package sops_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/stackgen-cli/envmerge/internal/resolver"
+	"github.com/stackgen-cli/envmerge/internal/secrets"
+	"github.com/stackgen-cli/envmerge/internal/sops"
+)
+
+// fixture builds files the way `sops --encrypt --age <recipient>` does
+type fixture struct {
+	t        *testing.T
+	identity *age.X25519Identity
+	dataKey  []byte
+	ageEnc   string
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fixture{t: t, identity: id, dataKey: make([]byte, 32)}
+	rand.Read(f.dataKey)
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, id.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(f.dataKey)
+	w.Close()
+	aw.Close()
+	f.ageEnc = buf.String()
+	return f
+}
+
+func (f *fixture) encrypt(key, value, typ string) string {
+	block, _ := aes.NewCipher(f.dataKey)
+	gcm, _ := cipher.NewGCMWithNonceSize(block, 32)
+	iv := make([]byte, 32)
+	rand.Read(iv)
+	out := gcm.Seal(nil, iv, []byte(value), []byte(key+":"))
+	data, tag := out[:len(out)-gcm.Overhead()], out[len(out)-gcm.Overhead():]
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", enc(data), enc(iv), enc(tag), typ)
+}
+
+func (f *fixture) keyFile() string {
+	path := filepath.Join(f.t.TempDir(), "keys.txt")
+	os.WriteFile(path, []byte("# test key\n"+f.identity.String()+"\n"), 0600)
+	return path
+}
+
+func (f *fixture) dotenv() string {
+	return "DB_PASSWORD=" + f.encrypt("DB_PASSWORD", `"s3cr3t value"`, "str") + "\n" +
+		"#ENC[AES256_GCM,data:abc=,iv:abc=,tag:abc=,type:comment]\n" +
+		"LOG_LEVEL=debug\n" +
+		"sops_age__list_0__map_enc=" + strings.ReplaceAll(f.ageEnc, "\n", `\n`) + "\n" +
+		"sops_age__list_0__map_recipient=" + f.identity.Recipient().String() + "\n" +
+		"sops_mac=" + f.encrypt("mac", "x", "str") + "\n" +
+		"sops_version=3.9.0\n"
+}
+
+func (f *fixture) json() string {
+	doc := map[string]interface{}{
+		"API_TOKEN": f.encrypt("API_TOKEN", "tok-123", "str"),
+		"DEBUG":     f.encrypt("DEBUG", "True", "bool"),
+		"REGION":    "eu-west-1",
+		"sops": map[string]interface{}{
+			"age":     []map[string]string{{"recipient": f.identity.Recipient().String(), "enc": f.ageEnc}},
+			"mac":     f.encrypt("mac", "x", "str"),
+			"version": "3.9.0",
+		},
+	}
+	data, _ := json.MarshalIndent(doc, "", "  ")
+	return string(data)
+}
+
+func (f *fixture) yaml() string {
+	indented := "            " + strings.ReplaceAll(strings.TrimSpace(f.ageEnc), "\n", "\n            ")
+	return "PORT: " + f.encrypt("PORT", "8080", "int") + "\n" +
+		"HOST: localhost\n" +
+		"nested:\n    KEY: value\n" +
+		"sops:\n    age:\n        - recipient: " + f.identity.Recipient().String() + "\n          enc: |\n" +
+		indented + "\n    version: 3.9.0\n"
+}
+
+func values(f *sops.File) map[string]string {
+	m := make(map[string]string)
+	for _, v := range f.Values {
+		m[v.Key] = v.Value
+	}
+	return m
+}
+
+func TestDecrypt_Formats(t *testing.T) {
+	fx := newFixture(t)
+	ids := []age.Identity{fx.identity}
+
+	tests := []struct {
+		name      string
+		content   string
+		format    string
+		want      map[string]string
+		encrypted []string
+	}{
+		{"dotenv", fx.dotenv(), sops.FormatDotenv,
+			map[string]string{"DB_PASSWORD": "s3cr3t value", "LOG_LEVEL": "debug"}, []string{"DB_PASSWORD"}},
+		{"json", fx.json(), sops.FormatJSON,
+			map[string]string{"API_TOKEN": "tok-123", "DEBUG": "true", "REGION": "eu-west-1"}, []string{"API_TOKEN", "DEBUG"}},
+		{"yaml", fx.yaml(), sops.FormatYAML,
+			map[string]string{"PORT": "8080", "HOST": "localhost"}, []string{"PORT"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := sops.Parse("secrets", []byte(tt.content))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if f.Format != tt.format {
+				t.Errorf("Format = %s, want %s", f.Format, tt.format)
+			}
+			if got := strings.Join(f.EncryptedKeys(), ","); got != strings.Join(tt.encrypted, ",") {
+				t.Errorf("EncryptedKeys = %s, want %s", got, strings.Join(tt.encrypted, ","))
+			}
+
+			if err := f.Decrypt(ids); err != nil {
+				t.Fatalf("Decrypt failed: %v", err)
+			}
+			got := values(f)
+			if len(got) != len(tt.want) {
+				t.Errorf("got keys %v, want %v", got, tt.want)
+			}
+			for k, want := range tt.want {
+				if got[k] != want {
+					t.Errorf("%s = %q, want %q", k, got[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecrypt_Identities(t *testing.T) {
+	fx := newFixture(t)
+	f, err := sops.Parse("secrets", []byte(fx.dotenv()))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if err := f.Decrypt(nil); !errors.Is(err, sops.ErrNoIdentity) {
+		t.Errorf("Decrypt without identity = %v, want ErrNoIdentity", err)
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	if err := f.Decrypt([]age.Identity{other}); err == nil {
+		t.Error("Decrypt with the wrong identity should fail")
+	}
+	if f.Decrypted || values(f)["DB_PASSWORD"] == "s3cr3t value" {
+		t.Error("failed decryption must leave values encrypted")
+	}
+}
+
+func TestIsEncrypted_PlainFiles(t *testing.T) {
+	for _, content := range []string{"A=1\nB=2\n", "{\"A\": 1}", "a: 1\nsops: nope\n"} {
+		if format, ok := sops.IsEncrypted([]byte(content)); ok {
+			t.Errorf("IsEncrypted(%q) = %s, want plain", content, format)
+		}
+	}
+}
+
+func TestResolve_SopsLayer(t *testing.T) {
+	fx := newFixture(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DB_PASSWORD=dev\nLOG_LEVEL=info\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.production.enc"), []byte(fx.dotenv()), 0644)
+
+	t.Setenv(sops.KeyFileEnv, "")
+	r, err := resolver.ResolveWithOptions(dir, resolver.Options{AgeKeyFile: fx.keyFile()})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+
+	pw := r.ByName["DB_PASSWORD"]
+	if pw.FinalValue != "s3cr3t value" || !pw.FinalFrom.Encrypted || !pw.Secret {
+		t.Errorf("DB_PASSWORD = %q (encrypted %v, secret %v)", pw.FinalValue, pw.FinalFrom.Encrypted, pw.Secret)
+	}
+	if v := r.ByName["LOG_LEVEL"]; v.FinalValue != "debug" || v.FinalFrom.Encrypted {
+		t.Errorf("LOG_LEVEL = %q, want plaintext debug", v.FinalValue)
+	}
+	if _, ok := r.ByName["sops_version"]; ok {
+		t.Error("SOPS metadata leaked into variables")
+	}
+	if len(r.EncryptedFiles) != 1 || !r.EncryptedFiles[0].Decrypted {
+		t.Fatalf("EncryptedFiles = %+v", r.EncryptedFiles)
+	}
+
+	redacted := secrets.RedactEncrypted(r)
+	if got := redacted.ByName["DB_PASSWORD"].FinalValue; got == "s3cr3t value" {
+		t.Error("RedactEncrypted left the decrypted value visible")
+	}
+	if got := redacted.ByName["LOG_LEVEL"].FinalValue; got != "debug" {
+		t.Errorf("RedactEncrypted masked LOG_LEVEL = %q", got)
+	}
+
+	// Without an identity the encrypted keys are unresolvable, not ciphertext
+	r, err = resolver.Resolve(dir)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if pw := r.ByName["DB_PASSWORD"]; pw.FinalValue != "" || !pw.FinalFrom.Unresolvable {
+		t.Errorf("DB_PASSWORD without key = %q (unresolvable %v)", pw.FinalValue, pw.FinalFrom.Unresolvable)
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "Cannot decrypt .env.production.enc") {
+		t.Errorf("Warnings = %v", r.Warnings)
+	}
+}
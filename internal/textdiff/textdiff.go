@@ -0,0 +1,161 @@
+// Package textdiff renders line-based unified diffs
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	a, b int // Line indexes in the old and new text
+}
+
+// Unified returns a unified diff between before and after, labelled with
+// the given file names. It returns "" when the texts are equal
+func Unified(fromName, toName, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	a, b := splitLines(before), splitLines(after)
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	for _, h := range hunks(ops) {
+		aStart, bStart := ops[h[0]].a, ops[h[0]].b
+		aCount, bCount := 0, 0
+		for _, o := range ops[h[0]:h[1]] {
+			if o.kind != opInsert {
+				aCount++
+			}
+			if o.kind != opDelete {
+				bCount++
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount)))
+		for _, o := range ops[h[0]:h[1]] {
+			switch o.kind {
+			case opEqual:
+				sb.WriteString(" " + a[o.a] + "\n")
+			case opDelete:
+				sb.WriteString("-" + a[o.a] + "\n")
+			case opInsert:
+				sb.WriteString("+" + b[o.b] + "\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines computes an edit script using the longest common subsequence.
+// Env files are small, so the quadratic table is fine
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, op{opEqual, i, j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{opInsert, i, j})
+			j++
+		default:
+			ops = append(ops, op{opDelete, i, j})
+			i++
+		}
+	}
+	return ops
+}
+
+// hunks groups changed ops with surrounding context into [start, end) ranges
+func hunks(ops []op) [][2]int {
+	var out [][2]int
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend while the next change is within two context windows
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == opEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*contextLines {
+				end += min(contextLines, run-end)
+				break
+			}
+			end = run
+		}
+
+		if len(out) > 0 && start <= out[len(out)-1][1] {
+			out[len(out)-1][1] = end
+		} else {
+			out = append(out, [2]int{start, end})
+		}
+		i = end - 1
+	}
+	return out
+}
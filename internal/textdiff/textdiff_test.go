@@ -0,0 +1,52 @@
+package textdiff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	before := "A=1\nB=2\nC=3\nD=4\nE=5\nF=6\nG=7\nH=8\nI=9\nJ=10\nK=11\nL=12\n"
+	after := "A=1\nB=two\nC=3\nD=4\nE=5\nF=6\nG=7\nH=8\nI=9\nJ=10\nL=12\nM=13\n"
+
+	want := `--- a/.env
++++ b/.env
+@@ -1,5 +1,5 @@
+ A=1
+-B=2
++B=two
+ C=3
+ D=4
+ E=5
+@@ -8,5 +8,5 @@
+ H=8
+ I=9
+ J=10
+-K=11
+ L=12
++M=13
+`
+	if got := Unified("a/.env", "b/.env", before, after); got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnified_NearbyChangesShareHunk(t *testing.T) {
+	before := "A=1\nB=2\nC=3\nD=4\n"
+	after := "A=one\nB=2\nC=3\nD=four\n"
+
+	want := "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-A=1\n+A=one\n B=2\n C=3\n-D=4\n+D=four\n"
+	if got := Unified("a", "b", before, after); got != want {
+		t.Errorf("Unified() = %q, want %q", got, want)
+	}
+}
+
+func TestUnified_Equal(t *testing.T) {
+	if got := Unified("a", "b", "X=1\n", "X=1\n"); got != "" {
+		t.Errorf("Unified() = %q, want empty", got)
+	}
+}
+
+func TestUnified_NewFile(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1 @@\n+X=1\n"
+	if got := Unified("a", "b", "", "X=1\n"); got != want {
+		t.Errorf("Unified() = %q, want %q", got, want)
+	}
+}